@@ -1,22 +1,72 @@
 package main
 
+import "google.golang.org/protobuf/encoding/protojson"
+import "google.golang.org/protobuf/encoding/protowire"
+import "github.com/yields/phony/pkg/jsonschema"
+import "github.com/yields/phony/pkg/protobuf"
+import "github.com/yields/phony/pkg/schema"
+import "github.com/yields/phony/pkg/server"
+import "github.com/yields/phony/pkg/format"
 import "github.com/yields/phony/pkg/phony"
+import "github.com/yields/phony/pkg/input"
+import "github.com/yields/phony/pkg/batch"
+import "github.com/yields/phony/pkg/stats"
+import "github.com/yields/phony/pkg/mask"
+import "github.com/yields/phony/pkg/rate"
+import "github.com/yields/phony/pkg/sink"
+import "google.golang.org/protobuf/proto"
 import "github.com/tj/docopt"
-import "math/rand"
+import "path/filepath"
+import "encoding/json"
+import "unicode/utf8"
 import "io/ioutil"
+import "math/rand"
+import "os/signal"
+import "net/http"
+import "runtime"
+import "context"
+import "syscall"
 import "strconv"
 import "strings"
-import "regexp"
+import "bytes"
+import "bufio"
+import "sync"
 import "sort"
 import "time"
 import "fmt"
 import "os"
+import "io"
 
 var usage = `
   Usage: phony
-    [--tick d]
+    [--tick d | --rate r | --profile file] [--arrival a]
     [--max n]
+    [--duration d]
+    [--stats d] [--metrics addr]
+    [--format f]
+    [--batch [--workers n]]
+    [--seed n] [--secret s]
+    [--locale list]
+    [-t file]... [-e expr | --templates path]
+    [--input f]
+    [--schema file | --columns list | --jsonschema ref]
+    [--delimiter c]
+    [--crlf]
+    [--table name]
+    [--dialect d]
+    [--rows n]
+    [--copy]
+    [--block-size n]
+    [--row-group n]
+    [--dir path]
+    [--proto file --message name [--mapping file]]
+    [--sink url [--method m] [--header h]... [--key k]
+      [--concurrency n] [--retries n] [--framing f]]
+    [--out pattern [--file-records n] [--file-size n] [--compress c]]
     [--list]
+    phony infer <file>
+    phony serve <config> [--addr a]
+    phony mask --rules file [--input f] [--seed n] [--secret s] [--locale list]
 
     phony -h | --help
     phony -v | --version
@@ -32,12 +82,135 @@ var usage = `
     # output a sigle name
     echo '{{ name }}' | phony --max 1
 
+    # output csv with a header row
+    phony --format csv --columns id=uuid,name,email
+
+    # output sql inserts, 100 rows per statement
+    phony --format sql --table users --rows 100 --schema users.json
+
+    # output related users and orders to ./seed/users.csv and ./seed/orders.csv
+    phony --format csv --schema shop.json --dir seed
+
+    # output request bodies conforming to an OpenAPI component
+    phony --jsonschema openapi.json#/components/schemas/User --max 10
+
+    # write a parquet file with 1m rows
+    phony --format parquet --schema users.json --max 1000000 --tick 1ns > users.parquet
+
+    # output length delimited protobuf messages
+    phony --format protobuf --proto user.proto --message acme.User
+
+    # post each user to an endpoint with 8 concurrent requests
+    phony --jsonschema openapi.json#/components/schemas/User --max 1000 \
+      --sink http://localhost:8080/users --concurrency 8 \
+      --header 'Idempotency-Key: {{ uuid }}'
+
+    # send statsd counters over udp
+    echo 'signups:1|c' | phony --sink udp://localhost:8125
+
+    # produce users to kafka, keyed by their id
+    phony --jsonschema user.json --sink 'kafka://localhost:9092/users?acks=all' --key '{{ .body.id }}'
+
+    # write 10m users to gzipped files of 1m records each
+    phony --jsonschema user.json --max 10000000 --tick 1ns \
+      --out users/users-%05d.ndjson --file-records 1000000 --compress gzip
+
+    # write 100m rows as fast as possible, the same rows every time
+    phony --format csv --schema users.json --max 100000000 --batch --seed 7 > users.csv
+
+    # ramp up to 5000 requests per second over 10 minutes, then hold
+    echo '{{ name }}' | phony --rate 'ramp:100/s,5000/s for 10m; 5000/s' --sink http://localhost:8080
+
+    # send 50 events per second on average with exponential gaps
+    echo '{{ event.action }}' | phony --rate 50/s --arrival poisson --seed 1
+
+    # soak test for 30 minutes
+    echo '{{ name }}' | phony --rate 100/s --duration 30m --sink http://localhost:8080
+
+    # print stats every 10s and expose prometheus metrics
+    echo '{{ name }}' | phony --rate 1000/s --stats 10s --metrics :9100 > /dev/null
+
+    # output users and orders to their own files, one -t per output
+    phony -t users.tmpl -t orders.tmpl --out 'data/{template}/%03d.ndjson'
+
+    # output a template given inline
+    phony -e '{"id":"{{ uuid }}","email":"{{ email }}"}' --max 10
+
+    # add a fake email to each user id of a database export
+    psql -Atc 'select id from users' | phony --input lines -e '{{ .in }},{{ email }}'
+
+    # output pageviews, clicks and purchases 80/15/5 from one file
+    # with "--- pageview 80" separators, {{ .template }} is the name
+    phony --templates events.tmpl --max 100
+
+    # output german, japanese and egyptian names and addresses
+    echo '{{ name }}, {{ address }}, {{ phone }}' | phony --locale de_DE,ja_JP,ar_EG
+
+    # infer a schema from a sample file
+    phony infer sample.csv > schema.json
+
+    # replace names, emails and ips of a production export
+    phony mask --rules rules.yaml < users.csv > users.masked.csv
+
+    # mask users and orders consistently, so joins on email still work
+    export PHONY_SECRET=...
+    phony mask --rules rules.yaml < users.csv > users.masked.csv
+    phony mask --rules rules.yaml < orders.csv > orders.masked.csv
+
+    # serve routes from routes.json, e.g GET /users?count=50&seed=7
+    phony serve routes.json --addr :8080
+
   Options:
-    --list          list all available generators
-    --max n         generate data up to n [default: -1]
-    --tick d        generate data every d [default: 10ms]
-    -v, --version   show version information
-    -h, --help      show help information
+    --list            list all available generators
+    -t, --template f  template file, each one renders to its own {template} sink or out
+    -e, --expr e      inline template
+    --templates path  weighted templates from a file or a directory, one per file
+    --secret s        secret of keyed values such as {{ email#.in.email }}, defaults to $PHONY_SECRET
+    --locale list     locales of generated values, e.g de_DE,ja_JP,ar_EG, others fall back to en_US
+    --rules file      mask rules, columns and the kind of value replacing them
+    --input f         render once per stdin record as {{ .in.field }}, lines, csv, tsv or ndjson
+    --max n           generate data up to n [default: -1]
+    --duration d      stop after d, e.g 5m, and exit with 124
+    --stats d         print records, bytes, rate, lag and sink errors to stderr every d
+    --metrics addr    serve prometheus metrics on addr/metrics, e.g :9100
+    --tick d          generate data every d [default: 10ms]
+    --rate r          generate data at rate r, e.g 5000/s or "ramp:0/s,1000/s for 5m; 1000/s"
+    --profile file    read a --rate profile from file, one stage per line
+    --arrival a       gaps between records, constant, poisson or uniform [default: constant]
+    --batch           generate as fast as possible with parallel workers, ignoring --tick
+    --workers n       batch workers, 0 for one per CPU [default: 0]
+    --seed n          seed generators to make output reproducible
+    --format f        output format, text, csv, tsv, sql, avro, parquet,
+                      protobuf or protojson [default: text]
+    --schema file     read fields from a JSON schema file
+    --columns list    comma separated fields, e.g "id=uuid,email"
+    --jsonschema ref  output JSON conforming to a JSON Schema file#pointer
+    --delimiter c     csv field delimiter, defaults to , or a tab for tsv
+    --crlf            terminate csv lines with \r\n
+    --table name      sql table or avro and parquet record name
+    --dialect d       sql dialect, postgres, mysql or sqlite [default: postgres]
+    --rows n          sql rows per INSERT statement [default: 1]
+    --copy            output a postgres COPY block instead of INSERTs
+    --block-size n    avro rows per block [default: 1000]
+    --row-group n     parquet rows per row group [default: 100000]
+    --dir path        write each schema entity to its own file in path
+    --proto file      read messages from a .proto file or a FileDescriptorSet
+    --message name    fully qualified protobuf message name
+    --mapping file    JSON object mapping message fields to generators
+    --sink url        send each record to url instead of stdout
+    --method m        http sink request method [default: POST]
+    --header h        http sink header, e.g "X-Id: {{ uuid }}", may be repeated
+    --concurrency n   concurrent sink requests [default: 1]
+    --retries n       retries of failed sink requests and writes [default: 3]
+    --framing f       socket sink framing, newline or octet [default: newline]
+    --key k           kafka sink message key template, e.g "{{ .body.id }}"
+    --out pattern     write records to files named by pattern, e.g out/users-%05d.ndjson
+    --file-records n  records per file before rotating
+    --file-size n     uncompressed bytes per file before rotating, e.g 100MB
+    --compress c      compress files with gzip or zstd
+    --addr a          serve address [default: :8080]
+    -v, --version     show version information
+    -h, --help        show help information
 
 `
 
@@ -56,7 +229,38 @@ func main() {
 		os.Exit(0)
 	}
 
-	rand.Seed(time.Now().UnixNano())
+	if args["infer"].(bool) {
+		b, err := ioutil.ReadFile(args["<file>"].(string))
+		check(err)
+		s, err := schema.Infer(b)
+		check(err)
+		b, err = json.MarshalIndent(s, "", "  ")
+		check(err)
+		fmt.Printf("%s\n", b)
+		os.Exit(0)
+	}
+
+	if args["serve"].(bool) {
+		srv, err := server.New(args["<config>"].(string))
+		check(err)
+		go srv.Watch(time.Second, func(err error) {
+			fmt.Fprintf(os.Stderr, "phony: %s\n", err)
+		})
+		check(http.ListenAndServe(args["--addr"].(string), srv))
+	}
+
+	if secret, ok := secret(args); ok {
+		phony.SetSecret(secret)
+	}
+
+	if v, ok := args["--locale"].(string); ok {
+		check(phony.SetLocale(strings.Split(v, ",")...))
+	}
+
+	if args["mask"].(bool) {
+		check(maskInput(args))
+		os.Exit(0)
+	}
 
 	d := parseDuration(args["--tick"].(string))
 	max := parseInt(args["--max"].(string))
@@ -66,37 +270,741 @@ func main() {
 		os.Exit(1)
 	}
 
-	tmpl := readAll(os.Stdin)
+	seed := time.Now().UnixNano()
+	if v, ok := args["--seed"].(string); ok {
+		seed = int64(parseInt(v))
+	}
+
+	var s *schema.Schema
+	switch args["--format"].(string) {
+	case "csv", "tsv", "sql", "avro", "parquet":
+		s = loadSchema(args)
+	}
+
+	if s != nil && len(s.Entities) > 0 {
+		if _, ok := args["--sink"].(string); ok {
+			check(fmt.Errorf("--sink does not support schema entities"))
+		}
+		if _, ok := args["--out"].(string); ok {
+			check(fmt.Errorf("--out does not support schema entities, use --dir"))
+		}
+		phony.Seed(seed)
+		check(entities(args, s))
+		return
+	}
+
+	var buf bytes.Buffer
+	var snks []sink.Sink
+	var out *bufio.Writer
+	w := io.Writer(os.Stdout)
+	names := templateNames(args)
+
+	if url, ok := args["--sink"].(string); ok {
+		for _, url := range expand(url, names) {
+			snks = append(snks, openSink(args, url))
+		}
+		w = &buf
+	}
+
+	if pattern, ok := args["--out"].(string); ok {
+		patterns := expand(pattern, names)
+		for i, pattern := range patterns {
+			manifest := "manifest.json"
+			if len(patterns) > 1 {
+				manifest = names[i] + ".manifest.json"
+			}
+			snks = append(snks, openFiles(args, pattern, manifest))
+		}
+		w = &buf
+	}
+
+	if args["--batch"].(bool) && snks == nil {
+		out = bufio.NewWriterSize(os.Stdout, 1<<16)
+		w = out
+	}
+
+	var errors func() int
+	if snks != nil {
+		errors = func() (n int) {
+			for _, snk := range snks {
+				n += snk.Errors()
+			}
+			return n
+		}
+	}
+
+	st := stats.New(errors)
+	o := newOutput(args, s, st.Writer(w))
+
+	// Multiple templates render a record each, the i-th
+	// is sent to the i-th sink when there are several.
+	emit := func(v interface{}) error {
+		rs, ok := v.(records)
+		if !ok {
+			rs = records{v}
+		}
+
+		for i, r := range rs {
+			var snk sink.Sink
+			if snks != nil {
+				snk = snks[i%len(snks)]
+			}
+
+			if f, ok := snk.(*sink.Files); ok && f.Full() {
+				if err := rotate(o, f, &buf); err != nil {
+					return err
+				}
+			}
+
+			if err := o.write(r); err != nil {
+				return err
+			}
+			st.Record()
+
+			// Network sinks receive a complete document
+			// per record, with its header or statement.
+			if _, ok := snk.(*sink.Files); !ok && snk != nil && o.reset != nil {
+				if err := o.reset(); err != nil {
+					return err
+				}
+			}
+
+			if snk != nil {
+				err := snk.Write(buf.Bytes())
+				buf.Reset()
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	done := make(chan struct{})
+
+	if v, ok := args["--stats"].(string); ok {
+		go st.Report(os.Stderr, parseDuration(v), done)
+	}
+
+	if addr, ok := args["--metrics"].(string); ok {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", st)
+		go func() { check(http.ListenAndServe(addr, mux)) }()
+	}
+
+	ctx, code := shutdown(args)
+	start := time.Now()
+	n := 0
 
-	ticker := time.NewTicker(d)
-	defer ticker.Stop()
-	f := compile(string(tmpl))
-	it := 0
+	if format, ok := args["--input"].(string); ok {
+		if o.render == nil {
+			check(fmt.Errorf("--input requires a template"))
+		}
+
+		if args["--batch"].(bool) {
+			check(fmt.Errorf("--batch does not support --input"))
+		}
 
-	for range ticker.C {
-		fmt.Fprintf(os.Stdout, "%s", f())
-		if it++; -1 != max && it == max {
-			return
+		r, err := input.NewReader(os.Stdin, format)
+		check(err)
+		phony.Seed(seed)
+
+		// Records are read in the background so that
+		// waiting for stdin doesn't delay stopping.
+		type record struct {
+			vars phony.Vars
+			err  error
 		}
+
+		records := make(chan record)
+		go func() {
+			for {
+				vars, err := r.Next()
+				select {
+				case records <- record{vars, err}:
+				case <-ctx.Done():
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+
+	read:
+		for -1 == max || n < max {
+			var rec record
+			select {
+			case <-ctx.Done():
+				break read
+			case rec = <-records:
+			}
+
+			if rec.err == io.EOF {
+				break
+			}
+			check(rec.err)
+			v, err := o.render(nil, rec.vars)
+			check(err)
+			check(emit(v))
+			n++
+		}
+	} else if args["--batch"].(bool) {
+		if args["--rate"] != nil || args["--profile"] != nil {
+			check(fmt.Errorf("--batch does not support --rate or --profile"))
+		}
+
+		opts := batch.Options{
+			Workers: parseInt(args["--workers"].(string)),
+			Max:     max,
+			Seed:    seed,
+		}
+
+		if opts.Workers == 0 {
+			opts.Workers = runtime.NumCPU()
+		}
+
+		if !o.parallel {
+			phony.Seed(opts.Seed)
+			opts.Workers = 1
+		}
+
+		n, err = batch.Run(ctx, opts, o.generate, emit)
+		check(err)
+	} else {
+		phony.Seed(seed)
+		p := pace(args, d, seed)
+
+	loop:
+		for due := p.Take(ctx); due > 0; due = p.Take(ctx) {
+			st.Lag(p.Lag())
+			for ; due > 0 && ctx.Err() == nil; due-- {
+				v, err := o.generate(nil)
+				check(err)
+				check(emit(v))
+				if n++; -1 != max && n == max {
+					break loop
+				}
+			}
+		}
+	}
+
+	close(done)
+	check(o.close())
+
+	if out != nil {
+		check(out.Flush())
+	}
+
+	// The end of the last document belongs to the current
+	// file, network sinks already received complete records.
+	if len(snks) > 0 && buf.Len() > 0 {
+		if f, ok := snks[0].(*sink.Files); ok {
+			check(f.Append(buf.Bytes()))
+		}
+	}
+
+	for i, snk := range snks {
+		check(snk.Close())
+		if len(snks) > 1 {
+			fmt.Fprintf(os.Stderr, "phony: %s: %s\n", names[i], snk.Summary())
+		} else {
+			fmt.Fprintf(os.Stderr, "phony: %s\n", snk.Summary())
+		}
+	}
+
+	if args["--batch"].(bool) || code() != 0 {
+		elapsed := time.Since(start)
+		fmt.Fprintf(os.Stderr, "phony: %d records in %s (%.0f records/s)\n",
+			n, elapsed.Round(time.Millisecond), float64(n)/elapsed.Seconds())
+	}
+
+	os.Exit(code())
+}
+
+// Secret returns the secret of keyed values from --secret or $PHONY_SECRET.
+func secret(args map[string]interface{}) ([]byte, bool) {
+	if v, ok := args["--secret"].(string); ok {
+		return []byte(v), true
 	}
+	v, ok := os.LookupEnv("PHONY_SECRET")
+	return []byte(v), ok
 }
 
-func compile(tmpl string) func() string {
-	expr, err := regexp.Compile(`({{ *(([a-zA-Z0-9]+(\.[a-zA-Z0-9]+)?)+(\:([a-zA-Z0-9,]+))?) *}})`)
+// Mask stdin to stdout with --rules, stdin is read as --input,
+// or as ndjson when it starts with "{" and as csv otherwise.
+func maskInput(args map[string]interface{}) error {
+	b, err := ioutil.ReadFile(args["--rules"].(string))
+	if err != nil {
+		return err
+	}
+
+	rules, err := mask.Parse(b)
+	if err != nil {
+		return err
+	}
+
+	m := mask.New(rules)
+	if v, ok := args["--seed"].(string); ok {
+		m.Seed(int64(parseInt(v)))
+	}
+
+	if secret, ok := secret(args); ok {
+		m.SetSecret(secret)
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	out := bufio.NewWriter(os.Stdout)
+
+	format, ok := args["--input"].(string)
+	if !ok {
+		format = "csv"
+		if c, _ := in.Peek(1); len(c) > 0 && c[0] == '{' {
+			format = "ndjson"
+		}
+	}
+
+	switch format {
+	case "csv":
+		err = m.CSV(in, out, ',')
+	case "tsv":
+		err = m.CSV(in, out, '\t')
+	case "ndjson":
+		err = m.NDJSON(in, out)
+	default:
+		err = fmt.Errorf("mask does not support --input %s", format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return out.Flush()
+}
+
+// Shutdown returns a context that is done after --duration,
+// exiting with 124, or on SIGINT or SIGTERM, exiting with 130
+// or 143, and a function returning the exit code. A second
+// signal exits immediately.
+func shutdown(args map[string]interface{}) (context.Context, func() int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var code int
+
+	stop := func(c int) {
+		mu.Lock()
+		if code == 0 {
+			code = c
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	if v, ok := args["--duration"].(string); ok {
+		time.AfterFunc(parseDuration(v), func() { stop(124) })
+	}
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		c := 128 + int((<-sigs).(syscall.Signal))
+		stop(c)
+		<-sigs
+		os.Exit(c)
+	}()
+
+	return ctx, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return code
+	}
+}
+
+// Pace returns the pacer of records, records are paced by --rate,
+// --profile or one every --tick, with gaps drawn from --arrival.
+func pace(args map[string]interface{}, d time.Duration, seed int64) rate.Pacer {
+	arrival := args["--arrival"].(string)
+	spec, ok := args["--rate"].(string)
+
+	if path, set := args["--profile"].(string); set {
+		b, err := ioutil.ReadFile(path)
+		check(err)
+		spec, ok = string(b), true
+	}
+
+	if !ok && arrival == "constant" {
+		return rate.NewTicker(d)
+	}
+
+	p := rate.Profile(rate.Constant(1 / d.Seconds()))
+	if ok {
+		var err error
+		p, err = rate.Parse(spec)
+		check(err)
+	}
+
+	if arrival == "constant" {
+		return rate.NewLimiter(p)
+	}
+
+	// Arrivals use their own source so they do not change the data.
+	a, err := rate.NewArrivals(p, arrival, rand.New(rand.NewSource(^seed)))
 	check(err)
-	return func() string {
-		return expr.ReplaceAllStringFunc(tmpl, func(s string) string {
-			call := strings.Trim(s[2:len(s)-2], " ")
-			parts := strings.Split(call, ":")
-			var arguments []string = nil
-			if len(parts) == 2 {
-				arguments = strings.Split(parts[1], ",")
-			}
-			data, err := phony.GetWithArgs(parts[0], arguments)
-			check(err)
-			return data
-		})
+	return a
+}
+
+// Records rendered by multiple templates, one per template.
+type records []interface{}
+
+// Output structure.
+//
+// Records are generated with a generator, which is nil for
+// the default one, and written in order. Generators that are
+// `parallel` may run concurrently with their own generator.
+// Templates can also `render` a record with variables.
+// Encoders with headers or statements can `reset` to end
+// the current document and start a new one.
+type output struct {
+	generate batch.Generate
+	render   func(g *phony.Generator, vars phony.Vars) (interface{}, error)
+	write    func(v interface{}) error
+	close    func() error
+	reset    func() error
+	parallel bool
+}
+
+func newOutput(args map[string]interface{}, s *schema.Schema, w io.Writer) *output {
+	if ref, ok := args["--jsonschema"].(string); ok {
+		g := loadJSONSchema(ref)
+		return &output{
+			generate: func(*phony.Generator) (interface{}, error) {
+				v, err := g.Generate()
+				if err != nil {
+					return nil, err
+				}
+				b, err := json.Marshal(v)
+				return append(b, '\n'), err
+			},
+			write: writeBytes(w),
+			close: func() error { return nil },
+		}
+	}
+
+	switch args["--format"].(string) {
+	case "protobuf", "protojson":
+		return protobufs(args, w)
+	}
+
+	if s == nil {
+		ts := loadTemplates(args)
+		render := func(g *phony.Generator, vars phony.Vars) (interface{}, error) {
+			if len(ts) == 1 {
+				data, err := ts[0].Render(g, vars)
+				return []byte(data), err
+			}
+
+			rs := make(records, len(ts))
+			for i, t := range ts {
+				data, err := t.Render(g, vars)
+				if err != nil {
+					return nil, err
+				}
+				rs[i] = []byte(data)
+			}
+
+			return rs, nil
+		}
+
+		return &output{
+			generate: func(g *phony.Generator) (interface{}, error) {
+				return render(g, nil)
+			},
+			render:   render,
+			write:    writeBytes(w),
+			close:    func() error { return nil },
+			parallel: true,
+		}
+	}
+
+	table, _ := args["--table"].(string)
+	enc := encoder(args, w, table, s.Fields)
+
+	return &output{
+		generate: func(g *phony.Generator) (interface{}, error) {
+			return s.GenerateWith(g)
+		},
+		write: func(v interface{}) error {
+			if err := enc.Encode(v.([]interface{})); err != nil {
+				return err
+			}
+			return enc.Flush()
+		},
+		close: func() error {
+			return enc.Close()
+		},
+		reset: func() error {
+			if err := enc.Close(); err != nil {
+				return err
+			}
+			enc = encoder(args, w, table, s.Fields)
+			return nil
+		},
+		parallel: true,
+	}
+}
+
+func protobufs(args map[string]interface{}, w io.Writer) *output {
+	path, ok := args["--proto"].(string)
+	if !ok {
+		check(fmt.Errorf("--format %s requires --proto", args["--format"]))
+	}
+
+	name, ok := args["--message"].(string)
+	if !ok {
+		check(fmt.Errorf("--format %s requires --message", args["--format"]))
+	}
+
+	desc, err := protobuf.Load(path, name)
+	check(err)
+
+	mapping := make(map[string]string)
+	if path, ok := args["--mapping"].(string); ok {
+		b, err := ioutil.ReadFile(path)
+		check(err)
+		check(json.Unmarshal(b, &mapping))
+	}
+
+	g := protobuf.New(desc, mapping)
+	binary := args["--format"].(string) == "protobuf"
+
+	return &output{
+		generate: func(*phony.Generator) (interface{}, error) {
+			m, err := g.Generate()
+			if err != nil {
+				return nil, err
+			}
+
+			if binary {
+				b, err := proto.Marshal(m)
+				return append(protowire.AppendVarint(nil, uint64(len(b))), b...), err
+			}
+
+			b, err := protojson.Marshal(m)
+			return append(b, '\n'), err
+		},
+		write: writeBytes(w),
+		close: func() error { return nil },
+	}
+}
+
+func writeBytes(w io.Writer) func(v interface{}) error {
+	return func(v interface{}) error {
+		_, err := w.Write(v.([]byte))
+		return err
+	}
+}
+
+func openSink(args map[string]interface{}, url string) sink.Sink {
+	switch args["--format"].(string) {
+	case "avro", "parquet":
+		check(fmt.Errorf("--sink does not support --format %s", args["--format"]))
+	case "sql":
+		if parseInt(args["--rows"].(string)) > 1 {
+			check(fmt.Errorf("--sink sends a statement per record, --rows must be 1"))
+		}
+	}
+
+	headers, _ := args["--header"].([]string)
+	key, _ := args["--key"].(string)
+	snk, err := sink.Open(url, sink.Options{
+		Method:      args["--method"].(string),
+		Headers:     headers,
+		Concurrency: parseInt(args["--concurrency"].(string)),
+		Retries:     parseInt(args["--retries"].(string)),
+		Framing:     args["--framing"].(string),
+		Key:         key,
+	})
+	check(err)
+	return snk
+}
+
+func openFiles(args map[string]interface{}, pattern, manifest string) sink.Sink {
+	if _, ok := args["--sink"].(string); ok {
+		check(fmt.Errorf("--out and --sink are mutually exclusive"))
+	}
+
+	switch args["--format"].(string) {
+	case "avro", "parquet":
+		check(fmt.Errorf("--out does not support --format %s", args["--format"]))
+	}
+
+	opts := sink.Options{Manifest: manifest}
+	opts.Compress, _ = args["--compress"].(string)
+
+	// Encoders end each file, see rotate.
+	switch args["--format"].(string) {
+	case "csv", "tsv", "sql":
+		opts.Manual = true
+	}
+
+	if n, ok := args["--file-records"].(string); ok {
+		opts.FileRecords = parseInt(n)
+	}
+
+	if n, ok := args["--file-size"].(string); ok {
+		opts.FileSize = parseSize(n)
+	}
+
+	snk, err := sink.NewFiles(pattern, opts)
+	check(err)
+	return snk
+}
+
+// Rotate `f`, ending the current document so that every
+// file starts with its own header or statement.
+func rotate(o *output, f *sink.Files, buf *bytes.Buffer) error {
+	if o.reset != nil {
+		if err := o.reset(); err != nil {
+			return err
+		}
+		if err := f.Append(buf.Bytes()); err != nil {
+			return err
+		}
+		buf.Reset()
+	}
+	return f.Rotate()
+}
+
+func entities(args map[string]interface{}, s *schema.Schema) error {
+	ext := args["--format"].(string)
+	dir, _ := args["--dir"].(string)
+
+	if dir == "" && ext != "sql" {
+		return fmt.Errorf("--format %s requires --dir with entities", ext)
+	}
+
+	for _, e := range s.Order() {
+		w := io.WriteCloser(os.Stdout)
+
+		if dir != "" {
+			f, err := os.Create(filepath.Join(dir, e.Name+"."+ext))
+			if err != nil {
+				return err
+			}
+			w = f
+		}
+
+		enc := encoder(args, w, e.Name, e.Fields)
+		if err := e.Generate(enc.Encode); err != nil {
+			return err
+		}
+
+		if err := enc.Close(); err != nil {
+			return err
+		}
+
+		if dir != "" {
+			if err := w.Close(); err != nil {
+				return err
+			}
+		}
 	}
+
+	return nil
+}
+
+func encoder(args map[string]interface{}, w io.Writer, table string, fields []*schema.Field) format.Encoder {
+	names := make([]string, len(fields))
+	columns := make([]format.Column, len(fields))
+
+	for i, f := range fields {
+		names[i] = f.Name
+		columns[i] = format.Column{Name: f.Name, Type: f.Kind(), Nullable: f.Nullable()}
+	}
+
+	name := table
+	if name == "" {
+		name = "phony"
+	}
+
+	switch args["--format"].(string) {
+	case "csv":
+		return format.NewCSV(w, names, delimiter(args, ','), args["--crlf"].(bool))
+	case "tsv":
+		return format.NewCSV(w, names, delimiter(args, '\t'), args["--crlf"].(bool))
+	case "avro":
+		enc, err := format.NewAvro(w, name, columns, parseInt(args["--block-size"].(string)))
+		check(err)
+		return enc
+	case "parquet":
+		enc, err := format.NewParquet(w, name, columns, parseInt(args["--row-group"].(string)))
+		check(err)
+		return enc
+	case "sql":
+		if table == "" {
+			check(fmt.Errorf("--format sql requires --table"))
+		}
+
+		d, err := format.DialectByName(args["--dialect"].(string))
+		check(err)
+
+		if args["--copy"].(bool) {
+			if d != format.Postgres {
+				check(fmt.Errorf("--copy requires --dialect postgres"))
+			}
+			return format.NewCopy(w, table, names)
+		}
+
+		return format.NewSQL(w, d, table, names, parseInt(args["--rows"].(string)))
+	default:
+		check(fmt.Errorf("unknown --format %q", args["--format"]))
+		return nil
+	}
+}
+
+func delimiter(args map[string]interface{}, comma rune) rune {
+	if c, ok := args["--delimiter"].(string); ok {
+		r, size := utf8.DecodeRuneInString(c)
+		if size == 0 || size != len(c) {
+			check(fmt.Errorf("--delimiter must be a single character, got %q", c))
+		}
+		comma = r
+	}
+	return comma
+}
+
+func loadSchema(args map[string]interface{}) *schema.Schema {
+	if list, ok := args["--columns"].(string); ok {
+		s, err := schema.Columns(list)
+		check(err)
+		return s
+	}
+
+	if path, ok := args["--schema"].(string); ok {
+		b, err := ioutil.ReadFile(path)
+		check(err)
+		s, err := schema.Parse(b)
+		check(err)
+		return s
+	}
+
+	check(fmt.Errorf("--format %s requires --schema or --columns", args["--format"]))
+	return nil
+}
+
+func loadJSONSchema(ref string) *jsonschema.Generator {
+	path, pointer := ref, ""
+	if i := strings.Index(ref, "#"); i != -1 {
+		path, pointer = ref[:i], ref[i+1:]
+	}
+
+	b, err := ioutil.ReadFile(path)
+	check(err)
+	g, err := jsonschema.Parse(b, pointer)
+	check(err)
+	return g
 }
 
 func check(err error) {
@@ -112,6 +1020,137 @@ func parseInt(s string) int {
 	return i
 }
 
+func parseSize(s string) int64 {
+	units := []struct {
+		suffix string
+		n      int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	n := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(strings.ToUpper(s), u.suffix) {
+			s, n = s[:len(s)-len(u.suffix)], u.n
+			break
+		}
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	check(err)
+	return i * n
+}
+
+// Load templates, one for each -t file, or one from -e, --templates
+// or stdin. A --templates directory holds one template per file,
+// named after the file without extension, and each --templates file
+// may hold several templates separated by "--- name weight" lines,
+// other templates are used as they are.
+func loadTemplates(args map[string]interface{}) []*phony.Templates {
+	if files := args["--template"].([]string); len(files) > 0 {
+		if args["--expr"] != nil || args["--templates"] != nil {
+			check(fmt.Errorf("-t can't be used with -e or --templates"))
+		}
+
+		all := make([]*phony.Templates, len(files))
+		for i, file := range files {
+			b, err := ioutil.ReadFile(file)
+			check(err)
+			all[i] = literal(templateName(file), string(b))
+		}
+		return all
+	}
+
+	if expr, ok := args["--expr"].(string); ok {
+		if !strings.HasSuffix(expr, "\n") {
+			expr += "\n"
+		}
+		return []*phony.Templates{literal("expr", expr)}
+	}
+
+	path, ok := args["--templates"].(string)
+	if !ok {
+		if args["--input"] != nil {
+			check(fmt.Errorf("--input reads stdin, use -t, -e or --templates"))
+		}
+		return []*phony.Templates{literal("stdin", readAll(os.Stdin))}
+	}
+
+	info, err := os.Stat(path)
+	check(err)
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*"))
+		check(err)
+	}
+
+	all := &phony.Templates{}
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		all.Merge(parseTemplates(file))
+	}
+
+	if len(all.Names()) == 0 {
+		check(fmt.Errorf("%s: no templates", path))
+	}
+
+	return []*phony.Templates{all}
+}
+
+// Return `text` as a single template called `name`.
+func literal(name, text string) *phony.Templates {
+	t := &phony.Templates{}
+	t.Add(name, 1, phony.Compile(text))
+	return t
+}
+
+// Parse the templates in `file`, named after the file.
+func parseTemplates(file string) *phony.Templates {
+	b, err := ioutil.ReadFile(file)
+	check(err)
+	t, err := phony.ParseTemplates(templateName(file), string(b))
+	check(err)
+	return t
+}
+
+// Names of the -t template files.
+func templateNames(args map[string]interface{}) []string {
+	var names []string
+	for _, file := range args["--template"].([]string) {
+		names = append(names, templateName(file))
+	}
+	return names
+}
+
+// Template name of `file`, its base name without extension.
+func templateName(file string) string {
+	return strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+}
+
+// Expand `s` into one string per template name when
+// it contains "{template}".
+func expand(s string, names []string) []string {
+	if !strings.Contains(s, "{template}") {
+		return []string{s}
+	}
+
+	if len(names) == 0 {
+		check(fmt.Errorf("{template} in %q requires -t", s))
+	}
+
+	all := make([]string, len(names))
+	for i, name := range names {
+		all[i] = strings.Replace(s, "{template}", name, -1)
+	}
+	return all
+}
+
 func parseDuration(s string) time.Duration {
 	d, err := time.ParseDuration(s)
 	check(err)
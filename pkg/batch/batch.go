@@ -0,0 +1,139 @@
+package batch
+
+import "github.com/yields/phony/pkg/phony"
+import "context"
+import "sync"
+
+// Options structure.
+type Options struct {
+	Workers int
+	Size    int
+	Max     int
+	Seed    int64
+}
+
+// Generate function, returns a record generated with `g`.
+type Generate func(g *phony.Generator) (interface{}, error)
+
+// Chunk structure.
+type chunk struct {
+	n       int
+	records []interface{}
+	err     error
+	done    chan struct{}
+}
+
+// Run generates `opts.Max` records, or until `fn` fails or `ctx`
+// is done, with `opts.Workers` goroutines and calls `fn` with each
+// record in order, returning the number of records passed to `fn`.
+//
+// Records are generated in chunks of `opts.Size`, chunk n is
+// generated with a generator seeded from `opts.Seed` and n so the
+// output depends on the seed and not on the number of workers.
+func Run(ctx context.Context, opts Options, gen Generate, fn func(interface{}) error) (int, error) {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+
+	if opts.Size < 1 {
+		opts.Size = 1000
+	}
+
+	jobs := make(chan *chunk)
+	pending := make(chan *chunk, 2*opts.Workers)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	defer wg.Wait()
+	defer close(stop)
+
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work(opts.Seed, gen, jobs)
+		}()
+	}
+
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+
+		for n, left := 0, opts.Max; left != 0; n++ {
+			size := opts.Size
+			if left > 0 && left < size {
+				size = left
+			}
+			if left > 0 {
+				left -= size
+			}
+
+			c := &chunk{n: n, records: make([]interface{}, size), done: make(chan struct{})}
+
+			select {
+			case pending <- c:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case jobs <- c:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	count := 0
+	for c := range pending {
+		<-c.done
+		if c.err != nil {
+			return count, c.err
+		}
+
+		for _, v := range c.records {
+			if ctx.Err() != nil {
+				return count, nil
+			}
+			if err := fn(v); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Mix `seed` and chunk `n` into the seed of the chunk with splitmix64,
+// so that chunks of nearby seeds don't overlap.
+func mix(seed int64, n int) int64 {
+	return int64(splitmix(splitmix(uint64(seed)) + uint64(n)))
+}
+
+// Splitmix64 step.
+func splitmix(z uint64) uint64 {
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Generate chunks from `jobs`.
+func work(seed int64, gen Generate, jobs chan *chunk) {
+	g := phony.Fork()
+
+	for c := range jobs {
+		g.Seed(mix(seed, c.n))
+
+		for i := range c.records {
+			if c.records[i], c.err = gen(g); c.err != nil {
+				break
+			}
+		}
+
+		close(c.done)
+	}
+}
@@ -0,0 +1,68 @@
+package batch
+
+import "github.com/yields/phony/pkg/phony"
+import "github.com/bmizerany/assert"
+import "testing"
+import "context"
+import "errors"
+
+func run(t *testing.T, workers int) []interface{} {
+	var ret []interface{}
+	tmpl := phony.Compile("{{ name }} {{ int:0,1000 }}")
+
+	n, err := Run(context.Background(), Options{Workers: workers, Size: 7, Max: 100, Seed: 42}, func(g *phony.Generator) (interface{}, error) {
+		return tmpl.ExecuteWith(g)
+	}, func(v interface{}) error {
+		ret = append(ret, v)
+		return nil
+	})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 100, n)
+	return ret
+}
+
+func TestRun(t *testing.T) {
+	a := run(t, 1)
+	b := run(t, 8)
+	assert.Equal(t, 100, len(a))
+	assert.Equal(t, a, b)
+}
+
+func TestSeeds(t *testing.T) {
+	seen := make(map[int64]bool)
+	for seed := int64(0); seed < 100; seed++ {
+		for n := 0; n < 100; n++ {
+			s := mix(seed, n)
+			assert.T(t, !seen[s], seed, n)
+			seen[s] = true
+		}
+	}
+}
+
+func TestRunError(t *testing.T) {
+	fail := errors.New("fail")
+
+	n, err := Run(context.Background(), Options{Workers: 4, Size: 10, Max: -1}, func(g *phony.Generator) (interface{}, error) {
+		return 1, nil
+	}, func(v interface{}) error {
+		return fail
+	})
+
+	assert.Equal(t, fail, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n, err := Run(ctx, Options{Workers: 2, Size: 10, Max: -1}, func(g *phony.Generator) (interface{}, error) {
+		return 1, nil
+	}, func(v interface{}) error {
+		cancel()
+		return nil
+	})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,186 @@
+package format
+
+import "encoding/binary"
+import "encoding/json"
+import "crypto/rand"
+import "strings"
+import "regexp"
+import "bufio"
+import "bytes"
+import "math"
+import "fmt"
+import "io"
+
+// Avro types by column type.
+var avroTypes = map[string]string{
+	"string": "string",
+	"int":    "long",
+	"float":  "double",
+	"bool":   "boolean",
+}
+
+// Invalid avro name characters.
+var avroName = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Avro encoder, writes an object container file.
+type Avro struct {
+	w       *bufio.Writer
+	columns []Column
+	header  []byte
+	sync    []byte
+	block   bytes.Buffer
+	rows    int
+	n       int
+}
+
+// NewAvro returns an avro encoder writing a record named `name`
+// with `columns`, blocks hold up to `rows` rows.
+func NewAvro(w io.Writer, name string, columns []Column, rows int) (*Avro, error) {
+	if rows < 1 {
+		rows = 1
+	}
+
+	fields := make([]map[string]interface{}, len(columns))
+	for i, c := range columns {
+		typ, ok := avroTypes[c.Type]
+		if !ok {
+			return nil, fmt.Errorf("format: avro: unknown type %q", c.Type)
+		}
+
+		fields[i] = map[string]interface{}{"name": sanitize(c.Name), "type": typ}
+		if c.Nullable {
+			fields[i]["type"] = []string{"null", typ}
+			fields[i]["default"] = nil
+		}
+	}
+
+	schema, err := json.Marshal(map[string]interface{}{
+		"type":   "record",
+		"name":   sanitize(name),
+		"fields": fields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Avro{
+		w:       bufio.NewWriter(w),
+		columns: columns,
+		sync:    make([]byte, 16),
+		rows:    rows,
+	}
+
+	if _, err := rand.Read(a.sync); err != nil {
+		return nil, err
+	}
+
+	var h bytes.Buffer
+	h.WriteString("Obj\x01")
+	putLong(&h, 2)
+	putBytes(&h, []byte("avro.schema"))
+	putBytes(&h, schema)
+	putBytes(&h, []byte("avro.codec"))
+	putBytes(&h, []byte("null"))
+	putLong(&h, 0)
+	h.Write(a.sync)
+	a.header = h.Bytes()
+
+	return a, nil
+}
+
+// Encode `row`.
+func (a *Avro) Encode(row []interface{}) error {
+	for i, v := range row {
+		c := a.columns[i]
+
+		if c.Nullable {
+			if v == nil {
+				putLong(&a.block, 0)
+				continue
+			}
+			putLong(&a.block, 1)
+		}
+
+		switch v := v.(type) {
+		case string:
+			putBytes(&a.block, []byte(v))
+		case int64:
+			putLong(&a.block, v)
+		case float64:
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+			a.block.Write(b[:])
+		case bool:
+			if v {
+				a.block.WriteByte(1)
+			} else {
+				a.block.WriteByte(0)
+			}
+		default:
+			return fmt.Errorf("format: avro: %s: unexpected value %v", c.Name, v)
+		}
+	}
+
+	if a.n++; a.n == a.rows {
+		return a.end()
+	}
+
+	return nil
+}
+
+// Flush written blocks.
+func (a *Avro) Flush() error {
+	return a.w.Flush()
+}
+
+// Close writes the last block and flushes.
+func (a *Avro) Close() error {
+	if err := a.end(); err != nil {
+		return err
+	}
+	return a.w.Flush()
+}
+
+// End the current block, writing the header first.
+func (a *Avro) end() error {
+	if a.header != nil {
+		a.w.Write(a.header)
+		a.header = nil
+	}
+
+	if a.n == 0 {
+		return nil
+	}
+
+	var b bytes.Buffer
+	putLong(&b, int64(a.n))
+	putLong(&b, int64(a.block.Len()))
+	a.w.Write(b.Bytes())
+	a.w.Write(a.block.Bytes())
+	_, err := a.w.Write(a.sync)
+
+	a.block.Reset()
+	a.n = 0
+	return err
+}
+
+// Write a zigzag encoded long.
+func putLong(b *bytes.Buffer, n int64) {
+	var buf [binary.MaxVarintLen64]byte
+	b.Write(buf[:binary.PutVarint(buf[:], n)])
+}
+
+// Write length prefixed bytes.
+func putBytes(b *bytes.Buffer, p []byte) {
+	putLong(b, int64(len(p)))
+	b.Write(p)
+}
+
+// Sanitize `s` into a valid avro name.
+func sanitize(s string) string {
+	s = avroName.ReplaceAllString(s, "_")
+	if s == "" || strings.IndexAny(s[:1], "0123456789") == 0 {
+		s = "_" + s
+	}
+	return s
+}
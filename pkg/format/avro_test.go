@@ -0,0 +1,46 @@
+package format
+
+import "github.com/linkedin/goavro/v2"
+import "github.com/bmizerany/assert"
+import "bytes"
+import "testing"
+
+func TestAvro(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewAvro(&buf, "users", []Column{
+		{Name: "id", Type: "int"},
+		{Name: "company.name", Type: "string", Nullable: true},
+		{Name: "score", Type: "float"},
+		{Name: "active", Type: "bool"},
+	}, 2)
+	assert.Equal(t, nil, err)
+
+	rows := [][]interface{}{
+		{int64(1), "Foo", 1.5, true},
+		{int64(-2), nil, 0.25, false},
+		{int64(3), "Bar", -3.0, true},
+	}
+
+	for _, row := range rows {
+		assert.Equal(t, nil, enc.Encode(row))
+	}
+	assert.Equal(t, nil, enc.Close())
+
+	r, err := goavro.NewOCFReader(&buf)
+	assert.Equal(t, nil, err)
+
+	var got []map[string]interface{}
+	for r.Scan() {
+		v, err := r.Read()
+		assert.Equal(t, nil, err)
+		got = append(got, v.(map[string]interface{}))
+	}
+
+	assert.Equal(t, nil, r.Err())
+	assert.Equal(t, 3, len(got))
+	assert.Equal(t, int64(-2), got[1]["id"])
+	assert.Equal(t, nil, got[1]["company_name"])
+	assert.Equal(t, map[string]interface{}{"string": "Bar"}, got[2]["company_name"])
+	assert.Equal(t, -3.0, got[2]["score"])
+	assert.Equal(t, false, got[1]["active"])
+}
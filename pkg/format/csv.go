@@ -0,0 +1,56 @@
+package format
+
+import "encoding/csv"
+import "io"
+
+// CSV encoder.
+type CSV struct {
+	w      *csv.Writer
+	header []string
+}
+
+// NewCSV returns a CSV encoder writing `header` before the
+// first row, `comma` is the field delimiter and `crlf`
+// terminates lines with \r\n.
+func NewCSV(w io.Writer, header []string, comma rune, crlf bool) *CSV {
+	c := csv.NewWriter(w)
+	c.Comma = comma
+	c.UseCRLF = crlf
+	return &CSV{w: c, header: header}
+}
+
+// Encode `row`.
+func (c *CSV) Encode(row []interface{}) error {
+	if c.header != nil {
+		if err := c.w.Write(c.header); err != nil {
+			return err
+		}
+		c.header = nil
+	}
+
+	rec := make([]string, len(row))
+	for i, v := range row {
+		rec[i] = text(v)
+	}
+
+	return c.w.Write(rec)
+}
+
+// Flush buffered rows.
+func (c *CSV) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Close writes the header if no rows were
+// written and flushes buffered rows.
+func (c *CSV) Close() error {
+	if c.header != nil {
+		if err := c.w.Write(c.header); err != nil {
+			return err
+		}
+		c.header = nil
+	}
+
+	return c.Flush()
+}
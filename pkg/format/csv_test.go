@@ -0,0 +1,22 @@
+package format
+
+import "github.com/bmizerany/assert"
+import "bytes"
+import "testing"
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewCSV(&buf, []string{"id", "company"}, ',', false)
+	assert.Equal(t, nil, enc.Encode([]interface{}{int64(1), "Foo, Inc"}))
+	assert.Equal(t, nil, enc.Encode([]interface{}{nil, `"Bar"`}))
+	assert.Equal(t, nil, enc.Flush())
+	assert.Equal(t, "id,company\n1,\"Foo, Inc\"\n,\"\"\"Bar\"\"\"\n", buf.String())
+}
+
+func TestTSV(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewCSV(&buf, []string{"a", "b"}, '\t', true)
+	assert.Equal(t, nil, enc.Encode([]interface{}{true, 2.5}))
+	assert.Equal(t, nil, enc.Flush())
+	assert.Equal(t, "a\tb\r\ntrue\t2.5\r\n", buf.String())
+}
@@ -0,0 +1,41 @@
+package format
+
+import "strconv"
+import "fmt"
+
+// Encoder writes generated rows.
+//
+// Flush writes buffered data and Close writes anything
+// pending, such as a partial batch, and flushes.
+type Encoder interface {
+	Encode(row []interface{}) error
+	Flush() error
+	Close() error
+}
+
+// Text returns the textual form of `v`, NULL is empty.
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Column structure.
+//
+// Type is one of "string", "int", "float" or "bool".
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
@@ -0,0 +1,88 @@
+package format
+
+import "github.com/parquet-go/parquet-go"
+import "fmt"
+import "io"
+
+// Parquet nodes by column type.
+var parquetTypes = map[string]func() parquet.Node{
+	"string": parquet.String,
+	"int":    func() parquet.Node { return parquet.Leaf(parquet.Int64Type) },
+	"float":  func() parquet.Node { return parquet.Leaf(parquet.DoubleType) },
+	"bool":   func() parquet.Node { return parquet.Leaf(parquet.BooleanType) },
+}
+
+// Parquet encoder.
+type Parquet struct {
+	w       *parquet.Writer
+	columns []Column
+	index   []int
+}
+
+// NewParquet returns a parquet encoder writing `columns` with
+// up to `rows` rows per row group.
+func NewParquet(w io.Writer, name string, columns []Column, rows int) (*Parquet, error) {
+	group := make(parquet.Group)
+
+	for _, c := range columns {
+		node, ok := parquetTypes[c.Type]
+		if !ok {
+			return nil, fmt.Errorf("format: parquet: unknown type %q", c.Type)
+		}
+
+		if c.Nullable {
+			group[c.Name] = parquet.Optional(node())
+		} else {
+			group[c.Name] = node()
+		}
+	}
+
+	schema := parquet.NewSchema(name, group)
+	index := make([]int, len(columns))
+
+	for i, path := range schema.Columns() {
+		for j, c := range columns {
+			if path[0] == c.Name {
+				index[j] = i
+			}
+		}
+	}
+
+	opts := []parquet.WriterOption{schema}
+	if rows > 0 {
+		opts = append(opts, parquet.MaxRowsPerRowGroup(int64(rows)))
+	}
+
+	return &Parquet{
+		w:       parquet.NewWriter(w, opts...),
+		columns: columns,
+		index:   index,
+	}, nil
+}
+
+// Encode `row`.
+func (p *Parquet) Encode(row []interface{}) error {
+	values := make(parquet.Row, len(row))
+
+	for i, v := range row {
+		def := 0
+		if p.columns[i].Nullable && v != nil {
+			def = 1
+		}
+
+		values[p.index[i]] = parquet.ValueOf(v).Level(0, def, p.index[i])
+	}
+
+	_, err := p.w.WriteRows([]parquet.Row{values})
+	return err
+}
+
+// Flush is a no-op, rows are written in row groups.
+func (p *Parquet) Flush() error {
+	return nil
+}
+
+// Close writes the last row group and the footer.
+func (p *Parquet) Close() error {
+	return p.w.Close()
+}
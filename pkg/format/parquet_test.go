@@ -0,0 +1,51 @@
+package format
+
+import "github.com/parquet-go/parquet-go"
+import "github.com/bmizerany/assert"
+import "bytes"
+import "testing"
+import "io"
+
+func TestParquet(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewParquet(&buf, "users", []Column{
+		{Name: "name", Type: "string"},
+		{Name: "id", Type: "int"},
+		{Name: "score", Type: "float", Nullable: true},
+		{Name: "active", Type: "bool"},
+	}, 2)
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 5; i++ {
+		var score interface{}
+		if i%2 == 0 {
+			score = float64(i) / 2
+		}
+		assert.Equal(t, nil, enc.Encode([]interface{}{"user", int64(i), score, i%2 == 0}))
+	}
+	assert.Equal(t, nil, enc.Close())
+
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(5), f.NumRows())
+	assert.Equal(t, 3, len(f.RowGroups()))
+
+	r := parquet.NewReader(f)
+	rows := make([]map[string]interface{}, 0)
+	for {
+		row := make(map[string]interface{})
+		if err := r.Read(&row); err == io.EOF {
+			break
+		} else {
+			assert.Equal(t, nil, err)
+		}
+		rows = append(rows, row)
+	}
+
+	assert.Equal(t, 5, len(rows))
+	assert.Equal(t, "user", rows[3]["name"])
+	assert.Equal(t, int64(3), rows[3]["id"])
+	assert.Equal(t, nil, rows[3]["score"])
+	assert.Equal(t, 1.0, rows[2]["score"])
+	assert.Equal(t, true, rows[2]["active"])
+}
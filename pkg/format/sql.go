@@ -0,0 +1,236 @@
+package format
+
+import "strings"
+import "strconv"
+import "bufio"
+import "math"
+import "fmt"
+import "io"
+
+// Dialect structure.
+type Dialect struct {
+	Name  string
+	ident func(string) string
+	str   func(string) string
+	bool  func(bool) string
+}
+
+// Dialects.
+var (
+	Postgres = &Dialect{
+		Name:  "postgres",
+		ident: quote(`"`, `"`),
+		str:   quote(`'`, `'`),
+		bool:  upper,
+	}
+	MySQL = &Dialect{
+		Name:  "mysql",
+		ident: quote("`", "`"),
+		str: func(s string) string {
+			return quote(`'`, `'`)(strings.Replace(s, `\`, `\\`, -1))
+		},
+		bool: upper,
+	}
+	SQLite = &Dialect{
+		Name:  "sqlite",
+		ident: quote(`"`, `"`),
+		str:   quote(`'`, `'`),
+		bool: func(b bool) string {
+			if b {
+				return "1"
+			}
+			return "0"
+		},
+	}
+)
+
+// DialectByName returns the dialect by `name`.
+func DialectByName(name string) (*Dialect, error) {
+	for _, d := range []*Dialect{Postgres, MySQL, SQLite} {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("format: unknown sql dialect %q", name)
+}
+
+// Literal returns `v` as an SQL literal.
+func (d *Dialect) Literal(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return d.str(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "NULL"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return d.bool(v)
+	default:
+		return d.str(fmt.Sprint(v))
+	}
+}
+
+// Ident returns `s` as a quoted identifier.
+func (d *Dialect) Ident(s string) string {
+	return d.ident(s)
+}
+
+// SQL encoder.
+type SQL struct {
+	w      *bufio.Writer
+	d      *Dialect
+	prefix string
+	rows   int
+	n      int
+}
+
+// NewSQL returns an encoder writing INSERT statements into
+// `table` with up to `rows` rows per statement.
+func NewSQL(w io.Writer, d *Dialect, table string, columns []string, rows int) *SQL {
+	if rows < 1 {
+		rows = 1
+	}
+
+	cols := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = d.Ident(c)
+	}
+
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", d.Ident(table), strings.Join(cols, ", "))
+
+	return &SQL{
+		w:      bufio.NewWriter(w),
+		d:      d,
+		prefix: prefix,
+		rows:   rows,
+	}
+}
+
+// Encode `row`.
+func (s *SQL) Encode(row []interface{}) error {
+	if s.n == 0 {
+		s.w.WriteString(s.prefix)
+	} else {
+		s.w.WriteString(", ")
+	}
+
+	s.w.WriteString("(")
+	for i, v := range row {
+		if i > 0 {
+			s.w.WriteString(", ")
+		}
+		s.w.WriteString(s.d.Literal(v))
+	}
+	s.w.WriteString(")")
+
+	if s.n++; s.n == s.rows {
+		s.n = 0
+		s.w.WriteString(";\n")
+	}
+
+	return nil
+}
+
+// Flush buffered data.
+func (s *SQL) Flush() error {
+	return s.w.Flush()
+}
+
+// Close terminates a partial statement and flushes.
+func (s *SQL) Close() error {
+	if s.n > 0 {
+		s.n = 0
+		s.w.WriteString(";\n")
+	}
+	return s.w.Flush()
+}
+
+// Copy encoder, writes a postgres `COPY ... FROM stdin` block.
+type Copy struct {
+	w      *bufio.Writer
+	header string
+}
+
+// NewCopy returns a postgres COPY encoder.
+func NewCopy(w io.Writer, table string, columns []string) *Copy {
+	cols := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = Postgres.Ident(c)
+	}
+
+	return &Copy{
+		w:      bufio.NewWriter(w),
+		header: fmt.Sprintf("COPY %s (%s) FROM stdin;\n", Postgres.Ident(table), strings.Join(cols, ", ")),
+	}
+}
+
+// Copy text format escapes.
+var escape = strings.NewReplacer(
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// Encode `row`.
+func (c *Copy) Encode(row []interface{}) error {
+	if c.header != "" {
+		c.w.WriteString(c.header)
+		c.header = ""
+	}
+
+	for i, v := range row {
+		if i > 0 {
+			c.w.WriteByte('\t')
+		}
+
+		switch v := v.(type) {
+		case nil:
+			c.w.WriteString(`\N`)
+		case bool:
+			if v {
+				c.w.WriteString("t")
+			} else {
+				c.w.WriteString("f")
+			}
+		default:
+			c.w.WriteString(escape.Replace(text(v)))
+		}
+	}
+
+	return c.w.WriteByte('\n')
+}
+
+// Flush buffered data.
+func (c *Copy) Flush() error {
+	return c.w.Flush()
+}
+
+// Close terminates the COPY block and flushes.
+func (c *Copy) Close() error {
+	if c.header == "" {
+		c.w.WriteString("\\.\n")
+	}
+	return c.w.Flush()
+}
+
+// Quote returns a function wrapping strings in `l` and `r`,
+// doubling any occurrence of `r`.
+func quote(l, r string) func(string) string {
+	return func(s string) string {
+		return l + strings.Replace(s, r, r+r, -1) + r
+	}
+}
+
+// Upper returns TRUE or FALSE.
+func upper(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
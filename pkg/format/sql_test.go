@@ -0,0 +1,41 @@
+package format
+
+import "github.com/bmizerany/assert"
+import "bytes"
+import "testing"
+
+func TestLiteral(t *testing.T) {
+	assert.Equal(t, "NULL", Postgres.Literal(nil))
+	assert.Equal(t, "42", Postgres.Literal(int64(42)))
+	assert.Equal(t, "0.5", Postgres.Literal(0.5))
+	assert.Equal(t, `'it''s \n'`, Postgres.Literal(`it's \n`))
+	assert.Equal(t, `'it''s \\n'`, MySQL.Literal(`it's \n`))
+	assert.Equal(t, "TRUE", MySQL.Literal(true))
+	assert.Equal(t, "0", SQLite.Literal(false))
+	assert.Equal(t, "`a``b`", MySQL.Ident("a`b"))
+}
+
+func TestSQL(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewSQL(&buf, Postgres, "users", []string{"id", "name"}, 2)
+	enc.Encode([]interface{}{int64(1), "a"})
+	enc.Encode([]interface{}{int64(2), nil})
+	enc.Encode([]interface{}{int64(3), "c"})
+	assert.Equal(t, nil, enc.Close())
+	assert.Equal(t, ""+
+		`INSERT INTO "users" ("id", "name") VALUES (1, 'a'), (2, NULL);`+"\n"+
+		`INSERT INTO "users" ("id", "name") VALUES (3, 'c');`+"\n", buf.String())
+}
+
+func TestCopy(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewCopy(&buf, "users", []string{"id", "bio"})
+	enc.Encode([]interface{}{int64(1), "a\tb\\c"})
+	enc.Encode([]interface{}{true, nil})
+	assert.Equal(t, nil, enc.Close())
+	assert.Equal(t, ""+
+		`COPY "users" ("id", "bio") FROM stdin;`+"\n"+
+		`1	a\tb\\c`+"\n"+
+		`t	\N`+"\n"+
+		`\.`+"\n", buf.String())
+}
@@ -0,0 +1,153 @@
+package input
+
+import "github.com/yields/phony/pkg/phony"
+import "encoding/json"
+import "encoding/csv"
+import "strconv"
+import "strings"
+import "regexp"
+import "bufio"
+import "fmt"
+import "io"
+
+// Maximum line length.
+const maxLine = 1 << 20
+
+// Characters that can't be in a variable name.
+var unsafe = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// Reader structure.
+//
+// Reader reads input records as template variables, the fields
+// of each record are available as `{{ .in.field }}`, nested NDJSON
+// fields as `{{ .in.user.id }}` and lines as `{{ .in }}`. Runs of
+// other characters than letters, digits and underscores in names
+// become an underscore, "First Name" is `{{ .in.First_Name }}`.
+type Reader struct {
+	next func() (phony.Vars, error)
+}
+
+// NewReader returns a reader of `format` records from `r`,
+// format is one of lines, csv, tsv or ndjson.
+func NewReader(r io.Reader, format string) (*Reader, error) {
+	switch format {
+	case "lines":
+		return lines(r), nil
+	case "csv":
+		return table(r, ','), nil
+	case "tsv":
+		return table(r, '\t'), nil
+	case "ndjson":
+		return ndjson(r), nil
+	default:
+		return nil, fmt.Errorf("input: unknown format %q", format)
+	}
+}
+
+// Next returns the next record, io.EOF when there are no more.
+func (r *Reader) Next() (phony.Vars, error) {
+	return r.next()
+}
+
+// Lines reader.
+func lines(r io.Reader) *Reader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), maxLine)
+
+	return &Reader{next: func() (phony.Vars, error) {
+		if !s.Scan() {
+			if err := s.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		return phony.Vars{"in": s.Text()}, nil
+	}}
+}
+
+// Table reader, the first row names the columns.
+func table(r io.Reader, comma rune) *Reader {
+	c := csv.NewReader(r)
+	c.Comma = comma
+	c.ReuseRecord = true
+
+	var header []string
+
+	return &Reader{next: func() (phony.Vars, error) {
+		if header == nil {
+			row, err := c.Read()
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range row {
+				header = append(header, key(name))
+			}
+		}
+
+		row, err := c.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		vars := make(phony.Vars, len(header))
+		for i, name := range header {
+			vars["in."+name] = row[i]
+		}
+
+		return vars, nil
+	}}
+}
+
+// NDJSON reader, each line is an object.
+func ndjson(r io.Reader) *Reader {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+
+	return &Reader{next: func() (phony.Vars, error) {
+		var v map[string]interface{}
+		if err := d.Decode(&v); err != nil {
+			if err == io.EOF {
+				return nil, err
+			}
+			return nil, fmt.Errorf("input: %s", err)
+		}
+
+		vars := make(phony.Vars, len(v))
+		Flatten(vars, "in", v)
+		return vars, nil
+	}}
+}
+
+// Flatten `v` into `vars` at `path`, objects and arrays
+// are available both as JSON and by their fields.
+func Flatten(vars phony.Vars, path string, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		vars[path] = ""
+	case string:
+		vars[path] = v
+	case bool:
+		vars[path] = strconv.FormatBool(v)
+	case json.Number:
+		vars[path] = v.String()
+	case map[string]interface{}:
+		for k, e := range v {
+			Flatten(vars, path+"."+key(k), e)
+		}
+		if path != "in" {
+			b, _ := json.Marshal(v)
+			vars[path] = string(b)
+		}
+	case []interface{}:
+		for i, e := range v {
+			Flatten(vars, path+"."+strconv.Itoa(i), e)
+		}
+		b, _ := json.Marshal(v)
+		vars[path] = string(b)
+	}
+}
+
+// Key returns `name` as a variable name.
+func key(name string) string {
+	return unsafe.ReplaceAllString(strings.TrimSpace(name), "_")
+}
@@ -0,0 +1,61 @@
+package input
+
+import "github.com/yields/phony/pkg/phony"
+import "github.com/bmizerany/assert"
+import "strings"
+import "testing"
+import "io"
+
+func read(t *testing.T, format, text string) []phony.Vars {
+	r, err := NewReader(strings.NewReader(text), format)
+	assert.Equal(t, nil, err)
+
+	var all []phony.Vars
+	for {
+		v, err := r.Next()
+		if err == io.EOF {
+			return all
+		}
+		assert.Equal(t, nil, err)
+		all = append(all, v)
+	}
+}
+
+func TestLines(t *testing.T) {
+	all := read(t, "lines", "a\nb c\n")
+	assert.Equal(t, []phony.Vars{{"in": "a"}, {"in": "b c"}}, all)
+}
+
+func TestCSV(t *testing.T) {
+	all := read(t, "csv", "user_id,plan\n1,pro\n2,free\n")
+	assert.Equal(t, 2, len(all))
+	assert.Equal(t, phony.Vars{"in.user_id": "2", "in.plan": "free"}, all[1])
+
+	all = read(t, "csv", "user-id,First Name\n1,Ada\n")
+	assert.Equal(t, phony.Vars{"in.user_id": "1", "in.First_Name": "Ada"}, all[0])
+}
+
+func TestNDJSON(t *testing.T) {
+	all := read(t, "ndjson", `{"id":7,"user":{"name":"x","admin":true},"tags":["a"],"n":null}`+"\n")
+	assert.Equal(t, 1, len(all))
+	assert.Equal(t, "7", all[0]["in.id"])
+	assert.Equal(t, "x", all[0]["in.user.name"])
+	assert.Equal(t, "true", all[0]["in.user.admin"])
+	assert.Equal(t, `{"admin":true,"name":"x"}`, all[0]["in.user"])
+	assert.Equal(t, "a", all[0]["in.tags.0"])
+	assert.Equal(t, "", all[0]["in.n"])
+
+	all = read(t, "ndjson", `{"user":{"e-mail":"a@b.c"}}`+"\n")
+	assert.Equal(t, "a@b.c", all[0]["in.user.e_mail"])
+
+	r, _ := NewReader(strings.NewReader("[1]\n"), "ndjson")
+	_, err := r.Next()
+	assert.NotEqual(t, nil, err)
+}
+
+func TestRender(t *testing.T) {
+	all := read(t, "csv", "user_id\n42\n")
+	s, err := phony.Compile(`{{ .in.user_id }}`).Render(nil, all[0])
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "42", s)
+}
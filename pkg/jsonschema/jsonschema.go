@@ -0,0 +1,563 @@
+package jsonschema
+
+import "github.com/yields/phony/pkg/schema"
+import "github.com/yields/phony/pkg/phony"
+import "encoding/base64"
+import "encoding/json"
+import "unicode/utf8"
+import "strconv"
+import "strings"
+import "regexp"
+import "math"
+import "sort"
+import "fmt"
+
+// Maximum nesting depth, optional properties and
+// array items beyond the minimum stop at `optionalDepth`.
+const (
+	maxDepth      = 32
+	optionalDepth = 4
+)
+
+// String formats to generator paths.
+var formats = map[string]string{
+	"email":        "email",
+	"idn-email":    "email",
+	"uuid":         "uuid",
+	"date-time":    "datetime",
+	"date":         "date",
+	"ipv4":         "ipv4",
+	"ipv6":         "ipv6",
+	"hostname":     "domain",
+	"idn-hostname": "domain",
+}
+
+// Schema structure, the subset of JSON Schema and
+// OpenAPI 3 schema objects used to generate values.
+type Schema struct {
+	Ref              string             `json:"$ref,omitempty"`
+	Type             types              `json:"type,omitempty"`
+	Enum             []interface{}      `json:"enum,omitempty"`
+	Const            json.RawMessage    `json:"const,omitempty"`
+	Format           string             `json:"format,omitempty"`
+	Pattern          string             `json:"pattern,omitempty"`
+	MinLength        *int               `json:"minLength,omitempty"`
+	MaxLength        *int               `json:"maxLength,omitempty"`
+	Minimum          *float64           `json:"minimum,omitempty"`
+	Maximum          *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum json.RawMessage    `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum json.RawMessage    `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64           `json:"multipleOf,omitempty"`
+	Properties       map[string]*Schema `json:"properties,omitempty"`
+	Required         []string           `json:"required,omitempty"`
+	Items            *Schema            `json:"items,omitempty"`
+	MinItems         *int               `json:"minItems,omitempty"`
+	MaxItems         *int               `json:"maxItems,omitempty"`
+	UniqueItems      bool               `json:"uniqueItems,omitempty"`
+	AllOf            []*Schema          `json:"allOf,omitempty"`
+	AnyOf            []*Schema          `json:"anyOf,omitempty"`
+	OneOf            []*Schema          `json:"oneOf,omitempty"`
+}
+
+// Types is a type name or a list of type names.
+type types []string
+
+// UnmarshalJSON implementation.
+func (t *types) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = types{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// Generator structure.
+type Generator struct {
+	doc  interface{}
+	root *Schema
+	refs map[string]*Schema
+}
+
+// Parse the JSON document `b` and return a generator for the
+// schema at the JSON `pointer`, e.g "/components/schemas/User",
+// an empty pointer is the whole document.
+func Parse(b []byte, pointer string) (*Generator, error) {
+	g := &Generator{refs: make(map[string]*Schema)}
+
+	if err := json.Unmarshal(b, &g.doc); err != nil {
+		return nil, fmt.Errorf("jsonschema: %s", err)
+	}
+
+	root, err := g.resolve("#" + pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	g.root = root
+	return g, nil
+}
+
+// Generate a value.
+func (g *Generator) Generate() (interface{}, error) {
+	return g.generate(g.root, "", 0)
+}
+
+// Generate a value for `s`, `name` is the property name.
+func (g *Generator) generate(s *Schema, name string, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("jsonschema: maximum depth exceeded at %q", name)
+	}
+
+	s, err := g.flatten(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Const != nil {
+		var v interface{}
+		err := json.Unmarshal(s.Const, &v)
+		return v, err
+	}
+
+	if len(s.Enum) > 0 {
+		return s.Enum[phony.Rand().Intn(len(s.Enum))], nil
+	}
+
+	switch s.typ() {
+	case "null":
+		return nil, nil
+	case "boolean":
+		return phony.Rand().Intn(2) == 0, nil
+	case "integer":
+		return s.integer()
+	case "number":
+		return s.number()
+	case "array":
+		return g.array(s, name, depth)
+	case "object":
+		return g.object(s, depth)
+	default:
+		return s.str(name)
+	}
+}
+
+// Flatten `s`, resolving references, merging `allOf` and
+// picking one of `anyOf` or `oneOf`.
+func (g *Generator) flatten(s *Schema) (*Schema, error) {
+	for i := 0; s.Ref != "" || len(s.AllOf) > 0 || len(s.AnyOf) > 0 || len(s.OneOf) > 0; i++ {
+		if i == maxDepth {
+			return nil, fmt.Errorf("jsonschema: circular $ref %q", s.Ref)
+		}
+
+		var subs []*Schema
+		rest := *s
+
+		switch {
+		case s.Ref != "":
+			ref, err := g.resolve(s.Ref)
+			if err != nil {
+				return nil, err
+			}
+			rest.Ref = ""
+			subs = []*Schema{ref}
+		case len(s.AllOf) > 0:
+			rest.AllOf = nil
+			subs = s.AllOf
+		case len(s.AnyOf) > 0:
+			rest.AnyOf = nil
+			subs = []*Schema{s.AnyOf[phony.Rand().Intn(len(s.AnyOf))]}
+		default:
+			rest.OneOf = nil
+			subs = []*Schema{s.OneOf[phony.Rand().Intn(len(s.OneOf))]}
+		}
+
+		merged := &rest
+		for _, sub := range subs {
+			m, err := merge(merged, sub)
+			if err != nil {
+				return nil, err
+			}
+			merged = m
+		}
+		s = merged
+	}
+
+	return s, nil
+}
+
+// Resolve a local `ref` such as "#/components/schemas/User".
+func (g *Generator) resolve(ref string) (*Schema, error) {
+	if s, ok := g.refs[ref]; ok {
+		return s, nil
+	}
+
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("jsonschema: unsupported $ref %q", ref)
+	}
+
+	node := g.doc
+	for _, tok := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if tok == "" {
+			continue
+		}
+
+		tok = strings.Replace(strings.Replace(tok, "~1", "/", -1), "~0", "~", -1)
+
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[tok]
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("jsonschema: invalid $ref %q", ref)
+			}
+			node = n[i]
+		default:
+			node = nil
+		}
+
+		if node == nil {
+			return nil, fmt.Errorf("jsonschema: $ref %q not found", ref)
+		}
+	}
+
+	b, err := json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Schema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("jsonschema: %s: %s", ref, err)
+	}
+
+	g.refs[ref] = &s
+	return &s, nil
+}
+
+// Object value.
+func (g *Generator) object(s *Schema, depth int) (interface{}, error) {
+	ret := make(map[string]interface{})
+	required := make(map[string]bool)
+
+	for _, name := range s.Required {
+		required[name] = true
+		if _, ok := s.Properties[name]; !ok {
+			v, err := g.generate(&Schema{}, name, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			ret[name] = v
+		}
+	}
+
+	// Sorted, map order would change seeded output.
+	for _, name := range keys(s.Properties) {
+		p := s.Properties[name]
+		if !required[name] && (depth >= optionalDepth || phony.Rand().Intn(2) == 0) {
+			continue
+		}
+
+		v, err := g.generate(p, name, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		ret[name] = v
+	}
+
+	return ret, nil
+}
+
+// Sorted property names.
+func keys(props map[string]*Schema) []string {
+	ret := make([]string, 0, len(props))
+	for k := range props {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Array value.
+func (g *Generator) array(s *Schema, name string, depth int) (interface{}, error) {
+	min, max := 0, 3
+	if s.MinItems != nil {
+		min, max = *s.MinItems, *s.MinItems+3
+	}
+	if s.MaxItems != nil && *s.MaxItems < max {
+		max = *s.MaxItems
+	}
+	if max < min || depth >= optionalDepth {
+		max = min
+	}
+
+	items := s.Items
+	if items == nil {
+		items = &Schema{}
+	}
+
+	n := min + phony.Rand().Intn(max-min+1)
+	ret := make([]interface{}, 0, n)
+	seen := make(map[string]bool)
+
+	for i := 0; len(ret) < n && i < n*10; i++ {
+		v, err := g.generate(items, name, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.UniqueItems {
+			b, _ := json.Marshal(v)
+			if seen[string(b)] {
+				continue
+			}
+			seen[string(b)] = true
+		}
+
+		ret = append(ret, v)
+	}
+
+	if len(ret) < min {
+		return nil, fmt.Errorf("jsonschema: cannot generate %d unique items for %q", min, name)
+	}
+
+	return ret, nil
+}
+
+// String value.
+func (s *Schema) str(name string) (interface{}, error) {
+	var expr *regexp.Regexp
+	if s.Pattern != "" {
+		var err error
+		if expr, err = regexp.Compile(s.Pattern); err != nil {
+			return nil, fmt.Errorf("jsonschema: %s", err)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		v, err := s.candidate(name, i < 10)
+		if err != nil {
+			return nil, err
+		}
+
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			continue
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			continue
+		}
+		if expr != nil && !expr.MatchString(v) {
+			continue
+		}
+
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("jsonschema: cannot generate a string for %q", name)
+}
+
+// Candidate string, generators matching the property
+// name are only used when `guess` is true.
+func (s *Schema) candidate(name string, guess bool) (string, error) {
+	switch s.Format {
+	case "uri", "url", "iri":
+		domain, _ := phony.Get("domain")
+		id, _ := phony.Get("id")
+		return "https://" + domain + "/" + id, nil
+	case "time":
+		t, err := phony.Get("datetime")
+		return t[strings.Index(t, "T")+1:], err
+	case "byte":
+		id, _ := phony.Get("id")
+		return base64.StdEncoding.EncodeToString([]byte(id)), nil
+	}
+
+	if path, ok := formats[s.Format]; ok {
+		return phony.Get(path)
+	}
+
+	if s.Pattern != "" {
+		return regex(s.Pattern)
+	}
+
+	if path := schema.Lookup(name); guess && path != "" {
+		return phony.Get(path)
+	}
+
+	min, max := 5, 15
+	if s.MinLength != nil {
+		min, max = *s.MinLength, *s.MinLength+10
+	}
+	if s.MaxLength != nil && *s.MaxLength < max {
+		max = *s.MaxLength
+	}
+	if max < min {
+		min = max
+	}
+
+	b := make([]byte, min+phony.Rand().Intn(max-min+1))
+	for i := range b {
+		b[i] = byte('a' + phony.Rand().Intn(26))
+	}
+	return string(b), nil
+}
+
+// Integer value.
+func (s *Schema) integer() (interface{}, error) {
+	lo, hi, xlo, xhi := s.bounds()
+
+	step := 1.0
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		step = *s.MultipleOf
+	}
+
+	v, err := multiple(lo, hi, xlo, xhi, step)
+	if err != nil {
+		return nil, err
+	}
+
+	return int64(v), nil
+}
+
+// Number value.
+func (s *Schema) number() (interface{}, error) {
+	lo, hi, xlo, xhi := s.bounds()
+
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		return multiple(lo, hi, xlo, xhi, *s.MultipleOf)
+	}
+
+	for i := 0; i < 10; i++ {
+		v := lo + phony.Rand().Float64()*(hi-lo)
+		if r := math.Round(v*100) / 100; r >= lo && r <= hi {
+			v = r
+		}
+		if (xlo && v == lo) || (xhi && v == hi) {
+			continue
+		}
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("jsonschema: cannot generate a number between %g and %g", lo, hi)
+}
+
+// Bounds returns the numeric range and whether
+// each end is exclusive.
+func (s *Schema) bounds() (lo, hi float64, xlo, xhi bool) {
+	lo, hi = 0, 1000
+
+	if s.Minimum != nil {
+		lo, hi = *s.Minimum, *s.Minimum+1000
+	}
+	if s.Maximum != nil {
+		hi = *s.Maximum
+		if s.Minimum == nil {
+			lo = hi - 1000
+		}
+	}
+
+	if v, ok := exclusive(s.ExclusiveMinimum); ok {
+		lo, xlo = v, true
+		if s.Maximum == nil {
+			hi = lo + 1000
+		}
+	} else if string(s.ExclusiveMinimum) == "true" {
+		xlo = true
+	}
+
+	if v, ok := exclusive(s.ExclusiveMaximum); ok {
+		hi, xhi = v, true
+		if s.Minimum == nil && s.ExclusiveMinimum == nil {
+			lo = hi - 1000
+		}
+	} else if string(s.ExclusiveMaximum) == "true" {
+		xhi = true
+	}
+
+	return lo, hi, xlo, xhi
+}
+
+// Type returns the type to generate.
+func (s *Schema) typ() string {
+	if len(s.Type) > 0 {
+		return s.Type[phony.Rand().Intn(len(s.Type))]
+	}
+
+	switch {
+	case s.Properties != nil || s.Required != nil:
+		return "object"
+	case s.Items != nil || s.MinItems != nil || s.MaxItems != nil:
+		return "array"
+	case s.Minimum != nil || s.Maximum != nil || s.MultipleOf != nil:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// Return a random multiple of `step` within the range.
+func multiple(lo, hi float64, xlo, xhi bool, step float64) (float64, error) {
+	min, max := math.Ceil(lo/step), math.Floor(hi/step)
+	if xlo && min*step == lo {
+		min++
+	}
+	if xhi && max*step == hi {
+		max--
+	}
+
+	if min > max {
+		return 0, fmt.Errorf("jsonschema: no multiple of %g between %g and %g", step, lo, hi)
+	}
+
+	return (min + float64(phony.Rand().Int63n(int64(max-min)+1))) * step, nil
+}
+
+// Exclusive returns the numeric form of exclusiveMinimum
+// or exclusiveMaximum, older drafts use a boolean instead.
+func exclusive(raw json.RawMessage) (float64, bool) {
+	var v float64
+	if raw == nil || json.Unmarshal(raw, &v) != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// Merge `b` into `a`, properties are merged and required
+// names are combined, any other keyword in `b` wins.
+func merge(a, b *Schema) (*Schema, error) {
+	var ret Schema
+	props := make(map[string]*Schema)
+
+	for _, s := range []*Schema{a, b} {
+		for _, k := range keys(s.Properties) {
+			v := s.Properties[k]
+			if p, ok := props[k]; ok {
+				m, err := merge(p, v)
+				if err != nil {
+					return nil, err
+				}
+				v = m
+			}
+			props[k] = v
+		}
+
+		blob, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(blob, &ret); err != nil {
+			return nil, err
+		}
+	}
+
+	ret.Properties = nil
+	if len(props) > 0 {
+		ret.Properties = props
+	}
+
+	ret.Required = append(append([]string{}, a.Required...), b.Required...)
+	return &ret, nil
+}
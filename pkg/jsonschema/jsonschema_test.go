@@ -0,0 +1,149 @@
+package jsonschema
+
+import validator "github.com/santhosh-tekuri/jsonschema/v5"
+import "github.com/yields/phony/pkg/phony"
+import "github.com/bmizerany/assert"
+import "encoding/json"
+import "strings"
+import "testing"
+
+var document = `{
+  "openapi": "3.0.0",
+  "components": {
+    "schemas": {
+      "User": {
+        "allOf": [{ "$ref": "#/components/schemas/Base" }],
+        "type": "object",
+        "required": ["email", "name", "role", "tags", "address", "age"],
+        "properties": {
+          "email": { "type": "string", "format": "email" },
+          "name": { "type": "string", "minLength": 3, "maxLength": 40 },
+          "username": { "type": "string" },
+          "role": { "enum": ["admin", "member"] },
+          "age": { "type": "integer", "minimum": 18, "exclusiveMaximum": 120 },
+          "score": { "type": "number", "minimum": 0, "maximum": 1 },
+          "balance": { "type": "number", "multipleOf": 0.25, "minimum": -10, "maximum": 10 },
+          "zip": { "type": "string", "pattern": "^[0-9]{5}(-[0-9]{4})?$" },
+          "website": { "type": "string", "format": "uri" },
+          "active": { "type": "boolean" },
+          "nickname": { "type": ["string", "null"], "maxLength": 4 },
+          "tags": {
+            "type": "array",
+            "minItems": 1,
+            "maxItems": 5,
+            "uniqueItems": true,
+            "items": { "type": "string", "enum": ["a", "b", "c", "d", "e", "f"] }
+          },
+          "address": { "$ref": "#/components/schemas/Address" },
+          "contact": {
+            "oneOf": [
+              { "type": "object", "required": ["phone"], "properties": { "phone": { "type": "string", "pattern": "^\\+1[0-9]{10}$" } } },
+              { "type": "object", "required": ["ip"], "properties": { "ip": { "type": "string", "format": "ipv4" } } }
+            ]
+          }
+        }
+      },
+      "Base": {
+        "type": "object",
+        "required": ["id", "created"],
+        "properties": {
+          "id": { "type": "string", "format": "uuid" },
+          "created": { "type": "string", "format": "date-time" },
+          "birthday": { "type": "string", "format": "date" }
+        }
+      },
+      "Address": {
+        "type": "object",
+        "required": ["country", "lines"],
+        "properties": {
+          "country": { "type": "string" },
+          "lines": { "type": "array", "items": { "type": "string" }, "maxItems": 2 },
+          "location": { "type": "array", "items": { "type": "number" }, "minItems": 2, "maxItems": 2 }
+        }
+      }
+    }
+  }
+}`
+
+func TestGenerate(t *testing.T) {
+	c := validator.NewCompiler()
+	c.AssertFormat = true
+	assert.Equal(t, nil, c.AddResource("spec.json", strings.NewReader(document)))
+	sch, err := c.Compile("spec.json#/components/schemas/User")
+	assert.Equal(t, nil, err)
+
+	g, err := Parse([]byte(document), "/components/schemas/User")
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 500; i++ {
+		v, err := g.Generate()
+		assert.Equal(t, nil, err)
+
+		// round trip so numbers are validated as json numbers.
+		b, err := json.Marshal(v)
+		assert.Equal(t, nil, err)
+		var doc interface{}
+		assert.Equal(t, nil, json.Unmarshal(b, &doc))
+
+		if err := sch.Validate(doc); err != nil {
+			t.Fatalf("%s: %s", b, err)
+		}
+	}
+}
+
+func TestRecursive(t *testing.T) {
+	g, err := Parse([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": { "type": "string" },
+			"children": { "type": "array", "items": { "$ref": "#" } }
+		}
+	}`), "")
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 50; i++ {
+		_, err := g.Generate()
+		assert.Equal(t, nil, err)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	g, err := Parse([]byte(`{
+		"type": "object",
+		"properties": {
+			"a": { "type": "string" },
+			"b": { "type": "integer" },
+			"c": { "type": "boolean" },
+			"d": { "type": "number" },
+			"e": { "type": "string", "format": "email" },
+			"f": { "type": "string", "format": "uuid" }
+		}
+	}`), "")
+	assert.Equal(t, nil, err)
+
+	run := func() string {
+		phony.Seed(7)
+		var ret []byte
+		for i := 0; i < 20; i++ {
+			v, err := g.Generate()
+			assert.Equal(t, nil, err)
+			b, err := json.Marshal(v)
+			assert.Equal(t, nil, err)
+			ret = append(ret, b...)
+		}
+		return string(ret)
+	}
+
+	assert.Equal(t, run(), run())
+}
+
+func TestErrors(t *testing.T) {
+	_, err := Parse([]byte(`{}`), "/missing")
+	assert.NotEqual(t, nil, err)
+
+	g, err := Parse([]byte(`{ "type": "integer", "minimum": 1, "maximum": 2, "multipleOf": 5 }`), "")
+	assert.Equal(t, nil, err)
+	_, err = g.Generate()
+	assert.NotEqual(t, nil, err)
+}
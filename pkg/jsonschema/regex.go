@@ -0,0 +1,71 @@
+package jsonschema
+
+import "github.com/yields/phony/pkg/phony"
+import "regexp/syntax"
+import "strings"
+
+// Maximum repetitions of unbounded operators.
+const maxRepeat = 4
+
+// Generate a random string matching `pattern`.
+func regex(pattern string) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	walk(&b, re.Simplify())
+	return b.String(), nil
+}
+
+// Walk `re` writing a random match to `b`.
+func walk(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return
+		}
+		i := phony.Rand().Intn(len(re.Rune)/2) * 2
+		lo, hi := re.Rune[i], re.Rune[i+1]
+		if lo < 0x20 && hi >= 0x20 {
+			lo = 0x20
+		}
+		if hi > 0x7e && lo <= 0x7e {
+			hi = 0x7e
+		}
+		b.WriteRune(lo + phony.Rand().Int31n(hi-lo+1))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune(rune('a' + phony.Rand().Intn(26)))
+	case syntax.OpCapture:
+		walk(b, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			walk(b, sub)
+		}
+	case syntax.OpAlternate:
+		walk(b, re.Sub[phony.Rand().Intn(len(re.Sub))])
+	case syntax.OpStar:
+		repeat(b, re.Sub[0], 0, maxRepeat)
+	case syntax.OpPlus:
+		repeat(b, re.Sub[0], 1, maxRepeat)
+	case syntax.OpQuest:
+		repeat(b, re.Sub[0], 0, 1)
+	case syntax.OpRepeat:
+		max := re.Max
+		if max == -1 {
+			max = re.Min + maxRepeat
+		}
+		repeat(b, re.Sub[0], re.Min, max)
+	}
+}
+
+// Walk `re` between `min` and `max` times.
+func repeat(b *strings.Builder, re *syntax.Regexp, min, max int) {
+	n := min + phony.Rand().Intn(max-min+1)
+	for i := 0; i < n; i++ {
+		walk(b, re)
+	}
+}
@@ -0,0 +1,393 @@
+package mask
+
+import "github.com/yields/phony/pkg/phony"
+import "gopkg.in/yaml.v3"
+import "encoding/json"
+import "encoding/csv"
+import "strconv"
+import "strings"
+import "bufio"
+import "bytes"
+import "fmt"
+import "net"
+import "io"
+
+// Maximum line length.
+const maxLine = 1 << 20
+
+// Rule structure.
+//
+// A rule replaces a column with a value of `kind`, either a
+// generator path such as "email", "phone", "ip" or "address"
+// or a `template` where `{{ .value }}` is the original value.
+//
+// `keep` preserves part of the original value, "domain" for
+// emails, a prefix such as "/24" for IPs and "format" for
+// phones, which keeps everything but the digits.
+type Rule struct {
+	Kind     string `yaml:"kind"`
+	Template string `yaml:"template"`
+	Keep     string `yaml:"keep"`
+	tmpl     *phony.Template
+	bits     int
+}
+
+// Rules structure.
+//
+// Columns are CSV column names or NDJSON fields,
+// nested fields are dotted, e.g "user.email".
+type Rules struct {
+	Columns map[string]*Rule `yaml:"columns"`
+}
+
+// Parse YAML rules.
+func Parse(b []byte) (*Rules, error) {
+	var r Rules
+
+	if err := yaml.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("mask: %s", err)
+	}
+
+	if len(r.Columns) == 0 {
+		return nil, fmt.Errorf("mask: no columns")
+	}
+
+	for name, rule := range r.Columns {
+		if rule == nil {
+			return nil, fmt.Errorf("mask: %s: kind or template is required", name)
+		}
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("mask: %s: %s", name, err)
+		}
+	}
+
+	return &r, nil
+}
+
+// UnmarshalYAML implementation, a rule may be just its kind.
+func (r *Rule) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		r.Kind = n.Value
+		return nil
+	}
+
+	type rule Rule
+	return n.Decode((*rule)(r))
+}
+
+// Compile the rule.
+func (r *Rule) compile() error {
+	if r.Template != "" {
+		r.tmpl = phony.Compile(r.Template)
+		if r.Keep != "" {
+			return fmt.Errorf("keep is not supported with a template")
+		}
+		return nil
+	}
+
+	switch r.Kind {
+	case "":
+		return fmt.Errorf("kind or template is required")
+	case "ip", "ipv4", "ipv6":
+		if r.Keep == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(r.Keep, "/"))
+		if err != nil || !strings.HasPrefix(r.Keep, "/") || n < 0 || n > 128 {
+			return fmt.Errorf("keep must be a prefix such as /24, got %q", r.Keep)
+		}
+		r.bits = n
+		return nil
+	}
+
+	if !known(r.Kind) {
+		return fmt.Errorf("unknown kind %q", r.Kind)
+	}
+
+	switch {
+	case r.Keep == "":
+	case r.Kind == "email" && r.Keep == "domain":
+	case r.Kind == "phone" && r.Keep == "format":
+	default:
+		return fmt.Errorf("%s does not support keep %q", r.Kind, r.Keep)
+	}
+
+	return nil
+}
+
+// Known reports whether `kind` is a generator path.
+func known(kind string) bool {
+	for _, path := range phony.List() {
+		if path == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Masker structure.
+type Masker struct {
+	rules *Rules
+	gen   *phony.Generator
+	keyed bool
+}
+
+// New returns a masker of `rules`.
+func New(rules *Rules) *Masker {
+	return &Masker{rules: rules, gen: phony.Fork()}
+}
+
+// Seed the masker, making the values it generates reproducible.
+func (m *Masker) Seed(seed int64) {
+	m.gen.Seed(seed)
+}
+
+// SetSecret makes masking consistent, the same value of the
+// same kind is always replaced with the same value in every
+// column, file and run, which keeps joins working.
+func (m *Masker) SetSecret(secret []byte) {
+	m.gen.SetSecret(secret)
+	m.keyed = true
+}
+
+// Value masks `v` of `column`, columns without a
+// rule and empty values are returned as is.
+func (m *Masker) Value(column, v string) (string, error) {
+	r, ok := m.rules.Columns[column]
+	if !ok || v == "" {
+		return v, nil
+	}
+
+	if m.keyed {
+		key := r.Kind
+		if r.tmpl != nil {
+			key = r.Template
+		}
+		return r.mask(m.gen.Keyed(key+"\x00"+v), v)
+	}
+
+	return r.mask(m.gen, v)
+}
+
+// Mask `v` with generator `g`.
+func (r *Rule) mask(g *phony.Generator, v string) (string, error) {
+	if r.tmpl != nil {
+		return r.tmpl.Render(g, phony.Vars{"value": v})
+	}
+
+	switch r.Kind {
+	case "ip", "ipv4", "ipv6":
+		return r.ip(g, v)
+	}
+
+	switch r.Keep {
+	case "domain":
+		s, err := g.Get("username")
+		if i := strings.LastIndex(v, "@"); i != -1 {
+			return s + v[i:], err
+		}
+		return s, err
+	case "format":
+		b := []byte(v)
+		for i, c := range b {
+			if c >= '0' && c <= '9' {
+				b[i] = '0' + byte(g.Rand().Intn(10))
+			}
+		}
+		return string(b), nil
+	}
+
+	return g.Get(r.Kind)
+}
+
+// Mask IP `v`, keeping the family and the leading `bits`.
+func (r *Rule) ip(g *phony.Generator, v string) (string, error) {
+	ip := net.ParseIP(v)
+
+	if ip == nil {
+		if r.Kind == "ip" {
+			return g.Get("ipv4")
+		}
+		return g.Get(r.Kind)
+	}
+
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+
+	if r.bits > len(ip)*8 {
+		return "", fmt.Errorf("mask: %s: prefix /%d is too long", v, r.bits)
+	}
+
+	ret := make(net.IP, len(ip))
+	g.Rand().Read(ret)
+
+	for i := range ret {
+		switch n := r.bits - i*8; {
+		case n >= 8:
+			ret[i] = ip[i]
+		case n > 0:
+			keep := byte(0xff << (8 - uint(n)))
+			ret[i] = ip[i]&keep | ret[i]&^keep
+		}
+	}
+
+	return ret.String(), nil
+}
+
+// CSV masks CSV records from `r` to `w`, the first row
+// names the columns and `comma` separates fields.
+func (m *Masker) CSV(r io.Reader, w io.Writer, comma rune) error {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		for i, v := range row {
+			if row[i], err = m.Value(header[i], v); err != nil {
+				return err
+			}
+		}
+
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// NDJSON masks NDJSON objects from `r` to `w`, the order of
+// fields and the values without a rule are kept as they are.
+func (m *Masker) NDJSON(r io.Reader, w io.Writer) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), maxLine)
+
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+
+		if len(line) > 0 {
+			var err error
+			if line, err = m.object(line, ""); err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return s.Err()
+}
+
+// Mask the fields of JSON object `raw` at `prefix`.
+func (m *Masker) object(raw []byte, prefix string) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+
+	if tok, err := d.Token(); err != nil || tok != json.Delim('{') {
+		if prefix == "" {
+			return nil, fmt.Errorf("mask: expected an object, got %.20q", raw)
+		}
+		return raw, nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i := 0; d.More(); i++ {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("mask: %s", err)
+		}
+
+		var v json.RawMessage
+		if err := d.Decode(&v); err != nil {
+			return nil, fmt.Errorf("mask: %s", err)
+		}
+
+		path := prefix + tok.(string)
+		if _, ok := m.rules.Columns[path]; ok {
+			v, err = m.scalar(path, v)
+		} else if m.nested(path + ".") {
+			v, err = m.object(v, path+".")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		k, _ := json.Marshal(tok)
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Mask the JSON string or number `v` at `path`, numbers stay
+// numbers when the masked value is a valid JSON number, other
+// values such as null are returned as is.
+func (m *Masker) scalar(path string, v json.RawMessage) (json.RawMessage, error) {
+	var s string
+	var number bool
+
+	switch {
+	case len(v) > 0 && v[0] == '"':
+		if err := json.Unmarshal(v, &s); err != nil {
+			return nil, fmt.Errorf("mask: %s", err)
+		}
+	case len(v) > 0 && (v[0] == '-' || v[0] >= '0' && v[0] <= '9'):
+		s, number = string(v), true
+	default:
+		return v, nil
+	}
+
+	s, err := m.Value(path, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, e := strconv.ParseFloat(s, 64); number && e == nil && json.Valid([]byte(s)) {
+		return json.RawMessage(s), nil
+	}
+
+	return json.Marshal(s)
+}
+
+// Nested reports whether a rule is nested under `prefix`.
+func (m *Masker) nested(prefix string) bool {
+	for name := range m.rules.Columns {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
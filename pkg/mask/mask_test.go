@@ -0,0 +1,128 @@
+package mask
+
+import "github.com/bmizerany/assert"
+import "encoding/json"
+import "strings"
+import "testing"
+import "bytes"
+import "net"
+
+const rules = `
+columns:
+  name: name
+  email:
+    kind: email
+    keep: domain
+  ip:
+    kind: ip
+    keep: /24
+  phone:
+    kind: phone
+    keep: format
+  user.address: address
+`
+
+func masker(t *testing.T) *Masker {
+	r, err := Parse([]byte(rules))
+	assert.Equal(t, nil, err)
+	return New(r)
+}
+
+func TestParse(t *testing.T) {
+	_, err := Parse([]byte("columns:\n  a: nope\n"))
+	assert.NotEqual(t, nil, err)
+
+	_, err = Parse([]byte("columns:\n  a:\n    kind: name\n    keep: domain\n"))
+	assert.NotEqual(t, nil, err)
+
+	_, err = Parse([]byte("columns:\n  a:\n    kind: ip\n    keep: 24\n"))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestCSV(t *testing.T) {
+	in := "id,name,email,ip,phone\n1,Jane Doe,jane@corp.com,10.1.2.3,+1 (555) 010-9999\n2,,,,\n"
+
+	var out bytes.Buffer
+	assert.Equal(t, nil, masker(t).CSV(strings.NewReader(in), &out, ','))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "id,name,email,ip,phone", lines[0])
+	assert.Equal(t, "2,,,,", lines[2])
+
+	row := strings.Split(lines[1], ",")
+	assert.Equal(t, "1", row[0])
+	assert.NotEqual(t, "Jane Doe", row[1])
+	assert.T(t, strings.HasSuffix(row[2], "@corp.com"), row[2])
+	assert.T(t, strings.HasPrefix(row[3], "10.1.2."), row[3])
+	assert.T(t, net.ParseIP(row[3]) != nil, row[3])
+	assert.Equal(t, len("+1 (555) 010-9999"), len(row[4]))
+	assert.T(t, strings.HasPrefix(row[4], "+"), row[4])
+}
+
+func TestNDJSON(t *testing.T) {
+	in := `{"id":1,"email":"a@b.io","user":{"address":"1 Main St","plan":"pro"},"ip":"2001:db8::1"}` + "\n"
+
+	var out bytes.Buffer
+	assert.Equal(t, nil, masker(t).NDJSON(strings.NewReader(in), &out))
+	assert.T(t, strings.HasPrefix(out.String(), `{"id":1,"email":"`), out.String())
+
+	var v struct {
+		Email string
+		IP    string
+		User  map[string]string
+	}
+
+	assert.Equal(t, nil, json.Unmarshal(out.Bytes(), &v))
+	assert.T(t, strings.HasSuffix(v.Email, "@b.io"), v.Email)
+	assert.NotEqual(t, "1 Main St", v.User["address"])
+	assert.Equal(t, "pro", v.User["plan"])
+	assert.Equal(t, net.ParseIP("2001:db8::1")[:3], net.ParseIP(v.IP)[:3])
+}
+
+func TestSecret(t *testing.T) {
+	r, err := Parse([]byte("columns:\n  a: email\n  b: email\n"))
+	assert.Equal(t, nil, err)
+
+	m := New(r)
+	m.SetSecret([]byte("s"))
+
+	var out bytes.Buffer
+	in := "a,b\nalice@corp.com,alice@corp.com\nbob@corp.com,alice@corp.com\n"
+	assert.Equal(t, nil, m.CSV(strings.NewReader(in), &out, ','))
+
+	rows := strings.Split(strings.TrimSpace(out.String()), "\n")
+	a, b := strings.Split(rows[1], ","), strings.Split(rows[2], ",")
+	assert.Equal(t, a[0], a[1])
+	assert.Equal(t, a[0], b[1])
+	assert.NotEqual(t, a[0], b[0])
+}
+
+func TestNDJSONTypes(t *testing.T) {
+	r, err := Parse([]byte("columns:\n  name: name\n  phone:\n    kind: phone\n    keep: format\n"))
+	assert.Equal(t, nil, err)
+	m := New(r)
+
+	for i := int64(0); i < 50; i++ {
+		m.Seed(i)
+
+		var out bytes.Buffer
+		in := `{"name":null,"phone":144531028}` + "\n"
+		assert.Equal(t, nil, m.NDJSON(strings.NewReader(in), &out))
+		assert.T(t, json.Valid(out.Bytes()), out.String())
+
+		var v map[string]interface{}
+		assert.Equal(t, nil, json.Unmarshal(out.Bytes(), &v))
+		assert.Equal(t, nil, v["name"])
+
+		switch p := v["phone"].(type) {
+		case float64:
+			assert.T(t, p >= 1e8, p)
+		case string:
+			assert.Equal(t, "0", p[:1])
+			assert.Equal(t, 9, len(p))
+		default:
+			t.Fatalf("unexpected phone %v", p)
+		}
+	}
+}
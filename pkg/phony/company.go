@@ -0,0 +1,88 @@
+package phony
+
+import "strconv"
+import "strings"
+import "math"
+import "fmt"
+
+// Company fields.
+var companyFields = []string{
+	"name",
+	"domain",
+	"website",
+	"email",
+	"industry",
+	"employees",
+	"founded",
+	"taxid",
+	"phone",
+	"street",
+	"city",
+	"state",
+	"zip",
+	"country",
+	"address",
+	"catchphrase",
+}
+
+func init() {
+	entities["company"] = company
+	for _, f := range companyFields {
+		gens["company."+f] = field("company", f)
+	}
+}
+
+// Generate a company, the domain, website and email are derived
+// from the name, the tax id, phone and address are in the locale
+// of the company and older companies tend to be larger.
+func company(g *Generator) (map[string]string, error) {
+	g = g.localized()
+	c := make(map[string]string)
+
+	names := g.set.dict["company.name"]
+	base := names[g.rand.Intn(len(names))]
+	suffix, _ := g.Get("company.suffix")
+	c["name"] = base + " " + suffix
+
+	tld, _ := g.Get("company.tld")
+	c["domain"] = slug(base) + "." + tld
+	c["website"] = "https://www." + c["domain"]
+	c["email"] = "info@" + c["domain"]
+
+	c["industry"], _ = g.Get("industry")
+
+	// Founding years skew recent, the headcount grows with age.
+	now := g.now().UTC().Year()
+	age := int(math.Exp(g.rand.Float64() * math.Log(120)))
+	c["founded"] = strconv.Itoa(now - age)
+	max := math.Min(50000, 10*float64(age*age))
+	c["employees"] = strconv.Itoa(1 + int(math.Exp(g.rand.Float64()*math.Log(max))))
+
+	c["taxid"], _ = g.Get("tax.id")
+	c["phone"], _ = g.Get("phone")
+
+	a, _ := g.Get("catchphrase.adjective")
+	d, _ := g.Get("catchphrase.descriptor")
+	n, _ := g.Get("catchphrase.noun")
+	c["catchphrase"] = a + " " + d + " " + n
+
+	if err := place(g, c); err != nil {
+		return nil, fmt.Errorf("company: %s", err)
+	}
+
+	return c, nil
+}
+
+// Return `name` as a domain label, e.g "Green-Plus" is "green-plus".
+func slug(name string) string {
+	b := make([]byte, 0, len(name))
+
+	for _, c := range strings.ToLower(name) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+			b = append(b, byte(c))
+		}
+	}
+
+	return strings.Trim(string(b), "-")
+}
@@ -2506,4 +2506,261 @@ var dict = map[string][]string{
 		"DELETE",
 		"OPTION",
 	},
+	"city": []string{
+		"New York",
+		"Los Angeles",
+		"Chicago",
+		"Houston",
+		"Phoenix",
+		"Philadelphia",
+		"San Antonio",
+		"San Diego",
+		"Dallas",
+		"Austin",
+		"Jacksonville",
+		"Columbus",
+		"Charlotte",
+		"Indianapolis",
+		"Seattle",
+		"Denver",
+		"Boston",
+		"Nashville",
+		"Portland",
+		"Las Vegas",
+		"Detroit",
+		"Memphis",
+		"Louisville",
+		"Baltimore",
+		"Milwaukee",
+		"Albuquerque",
+		"Tucson",
+		"Fresno",
+		"Sacramento",
+		"Kansas City",
+		"Atlanta",
+		"Omaha",
+		"Raleigh",
+		"Miami",
+		"Minneapolis",
+		"Tulsa",
+		"Cleveland",
+		"Oakland",
+		"Tampa",
+		"Pittsburgh",
+	},
+	"street.suffix": []string{
+		"Street",
+		"Avenue",
+		"Road",
+		"Lane",
+		"Drive",
+		"Court",
+		"Place",
+		"Boulevard",
+		"Way",
+		"Terrace",
+	},
+	"name.first.female": []string{
+		"Mary",
+		"Patricia",
+		"Jennifer",
+		"Linda",
+		"Elizabeth",
+		"Barbara",
+		"Susan",
+		"Jessica",
+		"Sarah",
+		"Karen",
+		"Lisa",
+		"Nancy",
+		"Betty",
+		"Margaret",
+		"Sandra",
+		"Ashley",
+		"Kimberly",
+		"Emily",
+		"Donna",
+		"Michelle",
+		"Carol",
+		"Amanda",
+		"Melissa",
+		"Deborah",
+		"Stephanie",
+		"Rebecca",
+		"Laura",
+		"Sharon",
+		"Cynthia",
+		"Kathleen",
+		"Amy",
+		"Angela",
+		"Anna",
+		"Emma",
+		"Olivia",
+		"Sophia",
+		"Grace",
+		"Chloe",
+		"Hannah",
+		"Natalie",
+	},
+	"name.first.male": []string{
+		"James",
+		"Robert",
+		"John",
+		"Michael",
+		"David",
+		"William",
+		"Richard",
+		"Joseph",
+		"Thomas",
+		"Charles",
+		"Christopher",
+		"Daniel",
+		"Matthew",
+		"Anthony",
+		"Mark",
+		"Donald",
+		"Steven",
+		"Paul",
+		"Andrew",
+		"Joshua",
+		"Kenneth",
+		"Kevin",
+		"Brian",
+		"George",
+		"Timothy",
+		"Ronald",
+		"Edward",
+		"Jason",
+		"Jeffrey",
+		"Ryan",
+		"Jacob",
+		"Gary",
+		"Nicholas",
+		"Eric",
+		"Jonathan",
+		"Stephen",
+		"Larry",
+		"Justin",
+		"Scott",
+		"Benjamin",
+	},
+	"job.title": []string{
+		"Software Engineer",
+		"Product Manager",
+		"Data Analyst",
+		"Accountant",
+		"Sales Representative",
+		"Marketing Manager",
+		"Graphic Designer",
+		"Nurse",
+		"Teacher",
+		"Mechanical Engineer",
+		"Customer Success Manager",
+		"Financial Analyst",
+		"Operations Manager",
+		"HR Specialist",
+		"Project Manager",
+		"Architect",
+		"Pharmacist",
+		"Electrician",
+		"Lawyer",
+		"Consultant",
+		"Chef",
+		"Photographer",
+		"Recruiter",
+		"Account Executive",
+		"Support Engineer",
+		"Office Manager",
+		"Research Scientist",
+		"UX Designer",
+		"DevOps Engineer",
+		"Chief Executive Officer",
+	},
+	"company.suffix": []string{
+		"Inc.",
+		"LLC",
+		"Corp.",
+		"Group",
+		"Ltd.",
+		"& Co.",
+	},
+	"company.tld": []string{
+		"com",
+		"io",
+		"co",
+		"net",
+	},
+	"industry": []string{
+		"Software",
+		"Financial Services",
+		"Healthcare",
+		"Retail",
+		"Manufacturing",
+		"Logistics",
+		"Telecommunications",
+		"Energy",
+		"Education",
+		"Media",
+		"Real Estate",
+		"Hospitality",
+		"Biotechnology",
+		"Insurance",
+		"Automotive",
+		"Agriculture",
+		"Construction",
+		"Consulting",
+		"E-commerce",
+		"Security",
+	},
+	"catchphrase.adjective": []string{
+		"Adaptive",
+		"Seamless",
+		"Scalable",
+		"Robust",
+		"Intuitive",
+		"Secure",
+		"Integrated",
+		"Innovative",
+		"Customer-focused",
+		"Streamlined",
+		"Data-driven",
+		"Sustainable",
+		"Reliable",
+		"Distributed",
+		"Proactive",
+	},
+	"catchphrase.descriptor": []string{
+		"real-time",
+		"cloud-native",
+		"end-to-end",
+		"next-generation",
+		"mission-critical",
+		"cross-platform",
+		"zero-defect",
+		"user-centric",
+		"high-performance",
+		"multi-tenant",
+		"open-source",
+		"value-added",
+		"enterprise-grade",
+		"context-aware",
+		"AI-powered",
+	},
+	"catchphrase.noun": []string{
+		"platform",
+		"solutions",
+		"infrastructure",
+		"analytics",
+		"workflows",
+		"services",
+		"partnerships",
+		"supply chains",
+		"experiences",
+		"architecture",
+		"insights",
+		"automation",
+		"networks",
+		"products",
+		"ecosystem",
+	},
 }
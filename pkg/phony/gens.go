@@ -2,7 +2,6 @@ package phony
 
 import (
 	"fmt"
-	"math/rand"
 	"strconv"
 	"time"
 
@@ -13,11 +12,14 @@ import (
 // Default gens.
 var gens = map[string]func(g *Generator, args []string) (string, error){
 	"now.utc": func(g *Generator, args []string) (string, error) {
-		return time.Now().UTC().Format(time.RFC3339), nil
+		return g.now().UTC().Format(time.RFC3339), nil
 	},
 	"name": func(g *Generator, args []string) (string, error) {
 		a, _ := g.Get("name.first")
 		b, _ := g.Get("name.last")
+		if g.locale != nil && g.locale.family {
+			return b + " " + a, nil
+		}
 		return a + " " + b, nil
 	},
 	"email": func(g *Generator, args []string) (string, error) {
@@ -36,42 +38,216 @@ var gens = map[string]func(g *Generator, args []string) (string, error){
 		return "https://s3.amazonaws.com/uifaces/faces/twitter/" + user + "/128.jpg", nil
 	},
 	"unixtime": func(g *Generator, args []string) (string, error) {
-		return strconv.FormatInt(time.Now().UnixNano(), 10), nil
+		return strconv.FormatInt(g.now().UnixNano(), 10), nil
 	},
 	"id": func(g *Generator, args []string) (string, error) {
 		chars := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 		ret := make([]rune, 10)
 
 		for i := range ret {
-			ret[i] = chars[rand.Intn(len(chars))]
+			ret[i] = chars[g.rand.Intn(len(chars))]
 		}
 
 		return string(ret), nil
 	},
 	"uuid": func(g *Generator, args []string) (string, error) {
-		return uuid.New().String(), nil
+		id, err := uuid.NewRandomFromReader(g.rand)
+		return id.String(), err
 	},
 	"ksuid": func(g *Generator, args []string) (string, error) {
-		return ksuid.New().String(), nil
+		payload := make([]byte, 16)
+		g.rand.Read(payload)
+		id, err := ksuid.FromParts(g.now(), payload)
+		return id.String(), err
 	},
 	"ipv4": func(g *Generator, args []string) (string, error) {
-		return fmt.Sprintf("%d.%d.%d.%d", 1+rand.Intn(253), rand.Intn(255), rand.Intn(255), 1+rand.Intn(253)), nil
+		return fmt.Sprintf("%d.%d.%d.%d", 1+g.rand.Intn(253), g.rand.Intn(255), g.rand.Intn(255), 1+g.rand.Intn(253)), nil
 	},
 	"ipv6": func(g *Generator, args []string) (string, error) {
-		return fmt.Sprintf("2001:cafe:%x:%x:%x:%x:%x:%x", rand.Intn(255), rand.Intn(255), rand.Intn(255), rand.Intn(255), rand.Intn(255), rand.Intn(255)), nil
+		return fmt.Sprintf("2001:cafe:%x:%x:%x:%x:%x:%x", g.rand.Intn(255), g.rand.Intn(255), g.rand.Intn(255), g.rand.Intn(255), g.rand.Intn(255), g.rand.Intn(255)), nil
 	},
 	"mac.address": func(g *Generator, args []string) (string, error) {
-		return fmt.Sprintf("%x:%x:%x:%x:%x:%x", rand.Intn(255), rand.Intn(255), rand.Intn(255), rand.Intn(255), rand.Intn(255), rand.Intn(255)), nil
+		return fmt.Sprintf("%x:%x:%x:%x:%x:%x", g.rand.Intn(255), g.rand.Intn(255), g.rand.Intn(255), g.rand.Intn(255), g.rand.Intn(255), g.rand.Intn(255)), nil
 	},
 	"latitude": func(g *Generator, args []string) (string, error) {
-		lattitude := (rand.Float64() * 180) - 90
+		lattitude := (g.rand.Float64() * 180) - 90
 		return strconv.FormatFloat(lattitude, 'f', 6, 64), nil
 	},
 	"longitude": func(g *Generator, args []string) (string, error) {
-		longitude := (rand.Float64() * 360) - 180
+		longitude := (g.rand.Float64() * 360) - 180
 		return strconv.FormatFloat(longitude, 'f', 6, 64), nil
 	},
 	"double": func(g *Generator, args []string) (string, error) {
-		return strconv.FormatFloat(rand.NormFloat64()*1000, 'f', 4, 64), nil
+		return strconv.FormatFloat(g.rand.NormFloat64()*1000, 'f', 4, 64), nil
+	},
+	"bool": func(g *Generator, args []string) (string, error) {
+		return strconv.FormatBool(g.rand.Intn(2) == 0), nil
+	},
+	"int": func(g *Generator, args []string) (string, error) {
+		min, max, err := ints(args, 0, 1000)
+		if err != nil {
+			return "", fmt.Errorf("int: %s", err)
+		}
+		return strconv.FormatInt(intn(g, min, max), 10), nil
+	},
+	"float": func(g *Generator, args []string) (string, error) {
+		var prec int64 = 4
+		if len(args) == 3 {
+			p, err := strconv.ParseInt(args[2], 10, 8)
+			if err != nil || p < 0 {
+				return "", fmt.Errorf("float: invalid precision %q", args[2])
+			}
+			prec, args = p, args[:2]
+		}
+
+		min, max, err := floats(args, 0, 1)
+		if err != nil {
+			return "", fmt.Errorf("float: %s", err)
+		}
+		return strconv.FormatFloat(min+g.rand.Float64()*(max-min), 'f', int(prec), 64), nil
+	},
+	"phone": func(g *Generator, args []string) (string, error) {
+		return fmt.Sprintf("(%d%02d) %d%02d-%04d", 2+g.rand.Intn(8), g.rand.Intn(100), 2+g.rand.Intn(8), g.rand.Intn(100), g.rand.Intn(10000)), nil
+	},
+	"tax.id": func(g *Generator, args []string) (string, error) {
+		return digits(g, "##-#######"), nil
+	},
+	"zip": func(g *Generator, args []string) (string, error) {
+		return fmt.Sprintf("%05d", 501+g.rand.Intn(99450)), nil
+	},
+	"street": func(g *Generator, args []string) (string, error) {
+		name, _ := g.Get("name.last")
+		suffix, _ := g.Get("street.suffix")
+		return fmt.Sprintf("%d %s %s", 1+g.rand.Intn(9999), name, suffix), nil
 	},
+	"address": func(g *Generator, args []string) (string, error) {
+		street, _ := g.Get("street")
+		city, _ := g.Get("city")
+		state, _ := g.Get("state.code")
+		zip, _ := g.Get("zip")
+		return street + ", " + city + ", " + state + " " + zip, nil
+	},
+	"date": func(g *Generator, args []string) (string, error) {
+		t, err := between(g, args)
+		if err != nil {
+			return "", fmt.Errorf("date: %s", err)
+		}
+		return t.Format("2006-01-02"), nil
+	},
+	"date.local": func(g *Generator, args []string) (string, error) {
+		t, err := between(g, args)
+		if err != nil {
+			return "", fmt.Errorf("date.local: %s", err)
+		}
+		if g.locale != nil {
+			return t.Format(g.locale.date), nil
+		}
+		return t.Format(locales["en_US"].date), nil
+	},
+	"datetime": func(g *Generator, args []string) (string, error) {
+		t, err := between(g, args)
+		if err != nil {
+			return "", fmt.Errorf("datetime: %s", err)
+		}
+		return t.Format(time.RFC3339), nil
+	},
+}
+
+// Return a random integer between `min` and `max` inclusive,
+// ranges wider than int64 are sampled from Uint64.
+func intn(g *Generator, min, max int64) int64 {
+	if n := max - min + 1; n > 0 {
+		return min + g.rand.Int63n(n)
+	}
+
+	n := uint64(max-min) + 1
+	if n == 0 {
+		return int64(g.rand.Uint64())
+	}
+
+	limit := ^uint64(0) - ^uint64(0)%n
+	v := g.rand.Uint64()
+	for v >= limit {
+		v = g.rand.Uint64()
+	}
+
+	return min + int64(v%n)
+}
+
+// Parse an integer range from `args`.
+func ints(args []string, min, max int64) (int64, int64, error) {
+	var err error
+
+	if len(args) == 2 {
+		if min, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+			return 0, 0, err
+		}
+		if max, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return 0, 0, err
+		}
+	} else if len(args) != 0 {
+		return 0, 0, fmt.Errorf("expected min,max got %q", args)
+	}
+
+	if min > max {
+		return 0, 0, fmt.Errorf("min %d is greater than max %d", min, max)
+	}
+
+	return min, max, nil
+}
+
+// Parse a float range from `args`.
+func floats(args []string, min, max float64) (float64, float64, error) {
+	var err error
+
+	if len(args) == 2 {
+		if min, err = strconv.ParseFloat(args[0], 64); err != nil {
+			return 0, 0, err
+		}
+		if max, err = strconv.ParseFloat(args[1], 64); err != nil {
+			return 0, 0, err
+		}
+	} else if len(args) != 0 {
+		return 0, 0, fmt.Errorf("expected min,max got %q", args)
+	}
+
+	if min > max {
+		return 0, 0, fmt.Errorf("min %g is greater than max %g", min, max)
+	}
+
+	return min, max, nil
+}
+
+// Return a random UTC time between `args`, each a date
+// or an RFC3339 timestamp, defaults to the unix epoch and now.
+func between(g *Generator, args []string) (time.Time, error) {
+	min, max := time.Unix(0, 0), g.now()
+
+	if len(args) == 2 {
+		var err error
+		if min, err = parseTime(args[0]); err != nil {
+			return min, err
+		}
+		if max, err = parseTime(args[1]); err != nil {
+			return max, err
+		}
+	} else if len(args) != 0 {
+		return min, fmt.Errorf("expected from,to got %q", args)
+	}
+
+	if min.After(max) {
+		return min, fmt.Errorf("%s is after %s", args[0], args[1])
+	}
+
+	// Seconds, time.Duration overflows after ~292 years.
+	d := max.Unix() - min.Unix()
+	return time.Unix(min.Unix()+g.rand.Int63n(d+1), 0).UTC(), nil
+}
+
+// Parse a date or an RFC3339 timestamp.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
 }
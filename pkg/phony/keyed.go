@@ -0,0 +1,59 @@
+package phony
+
+import "encoding/binary"
+import "crypto/sha256"
+import "crypto/hmac"
+import "math/rand"
+import "strings"
+import "time"
+
+// Time of keyed generators, so that the dates
+// and ages of a key don't change with the clock.
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SetSecret sets the secret of keyed generation, without
+// it keyed values can be guessed from their keys.
+func (g *Generator) SetSecret(secret []byte) {
+	g.secret = secret
+}
+
+// Keyed returns a generator seeded from the HMAC of `key`
+// with the secret, it generates the same values for the
+// same key and secret on every run and machine.
+func (g *Generator) Keyed(key string) *Generator {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(key))
+	seed := int64(binary.BigEndian.Uint64(mac.Sum(nil)))
+
+	src := &source{src: rand.NewSource(seed).(rand.Source64)}
+	return &Generator{set: g.set, rand: rand.New(src), secret: g.secret, locales: g.locales, locale: g.locale, epoch: epoch}
+}
+
+// GetKeyed returns `path` for `key`, the same key
+// always maps to the same value.
+func (g *Generator) GetKeyed(path, key string) (string, error) {
+	return g.GetKeyedWithArgs(path, nil, key)
+}
+
+// GetKeyedWithArgs returns `path` with `args` for `key`.
+//
+// Fields of entities are keyed by the entity, so that
+// `{{ person.name#.in.id }}` and `{{ person.email#.in.id }}`
+// are of the same person.
+func (g *Generator) GetKeyedWithArgs(path string, args []string, key string) (string, error) {
+	name := path
+	if i := strings.Index(path, "."); i != -1 && entities[path[:i]] != nil {
+		name = path[:i]
+	}
+	return g.Keyed(name+"\x00"+key).Record().GetWithArgs(path, args)
+}
+
+// SetSecret sets the secret of the default generator.
+func SetSecret(secret []byte) {
+	gen.SetSecret(secret)
+}
+
+// GetKeyed returns `path` for `key` with the default generator.
+func GetKeyed(path, key string) (string, error) {
+	return gen.GetKeyed(path, key)
+}
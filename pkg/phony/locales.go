@@ -0,0 +1,702 @@
+package phony
+
+import "strings"
+import "sort"
+import "fmt"
+
+// Locale structure.
+//
+// A locale overrides dictionary paths and `formats`,
+// templates where each "#" is a random digit, other
+// paths fall back to the default dataset. Names start
+// with the last name when `family` is set.
+type Locale struct {
+	dict    map[string][]string
+	formats map[string]string
+	date    string
+	family  bool
+}
+
+// Locales by name, en_US is the default dataset.
+var locales = map[string]*Locale{
+	"en_US": &Locale{date: "01/02/2006"},
+	"ar_EG": &Locale{
+		dict: map[string][]string{
+			"company.suffix": []string{
+				"ش.م.م",
+				"ش.م.م.",
+				"للتجارة",
+				"القابضة",
+			},
+			"company.tld": []string{
+				"com.eg",
+				"eg",
+			},
+			"name.first.female": []string{
+				"فاطمة",
+				"مريم",
+				"نور",
+				"سارة",
+				"هدى",
+				"منى",
+				"ياسمين",
+				"آية",
+				"دينا",
+				"رانيا",
+				"سلمى",
+				"ليلى",
+				"أمل",
+				"هبة",
+				"شيماء",
+				"نادية",
+				"إيمان",
+				"رحمة",
+				"جميلة",
+				"خديجة",
+			},
+			"name.first.male": []string{
+				"محمد",
+				"أحمد",
+				"محمود",
+				"مصطفى",
+				"علي",
+				"عمر",
+				"يوسف",
+				"إبراهيم",
+				"حسن",
+				"حسين",
+				"خالد",
+				"طارق",
+				"كريم",
+				"عمرو",
+				"سامي",
+				"ياسر",
+				"وليد",
+				"هشام",
+				"شريف",
+				"عبدالله",
+			},
+			"name.last": []string{
+				"عبدالرحمن",
+				"حسن",
+				"إبراهيم",
+				"محمود",
+				"السيد",
+				"عبدالله",
+				"علي",
+				"مصطفى",
+				"سليمان",
+				"فؤاد",
+				"منصور",
+				"الشريف",
+				"النجار",
+				"عثمان",
+				"رمضان",
+				"صالح",
+				"الجمال",
+				"عادل",
+				"فاروق",
+				"زكي",
+			},
+			"city": []string{
+				"القاهرة",
+				"الإسكندرية",
+				"الجيزة",
+				"شبرا الخيمة",
+				"بورسعيد",
+				"السويس",
+				"الأقصر",
+				"المنصورة",
+				"طنطا",
+				"أسيوط",
+				"الإسماعيلية",
+				"الفيوم",
+				"الزقازيق",
+				"أسوان",
+				"دمياط",
+				"المنيا",
+			},
+			"state": []string{
+				"القاهرة",
+				"الجيزة",
+				"الإسكندرية",
+				"الدقهلية",
+				"الشرقية",
+				"القليوبية",
+				"الغربية",
+				"المنوفية",
+				"البحيرة",
+				"أسيوط",
+				"سوهاج",
+				"قنا",
+				"الأقصر",
+				"أسوان",
+				"بورسعيد",
+				"السويس",
+			},
+			"street.name": []string{
+				"التحرير",
+				"الجمهورية",
+				"النيل",
+				"الهرم",
+				"رمسيس",
+				"طلعت حرب",
+				"الجلاء",
+				"الثورة",
+				"صلاح سالم",
+				"قصر النيل",
+				"محمد فريد",
+				"البحر الأعظم",
+			},
+			"country": []string{
+				"مصر",
+			},
+			"country.code": []string{
+				"EG",
+			},
+		},
+		formats: map[string]string{
+			"tax.id":  "###-###-###",
+			"street":  "{{ int:1,200 }} شارع {{ street.name }}",
+			"address": "{{ street }}، {{ city }}، {{ state }} {{ zip }}",
+			"zip":     "#####",
+			"phone":   "+20 10# ### ####",
+		},
+		date: "02/01/2006",
+	},
+	"de_DE": &Locale{
+		dict: map[string][]string{
+			"company.suffix": []string{
+				"GmbH",
+				"AG",
+				"KG",
+				"GmbH & Co. KG",
+				"SE",
+			},
+			"company.tld": []string{
+				"de",
+				"com",
+			},
+			"name.first.female": []string{
+				"Anna",
+				"Emma",
+				"Hannah",
+				"Lea",
+				"Lena",
+				"Marie",
+				"Mia",
+				"Sophie",
+				"Laura",
+				"Julia",
+				"Katharina",
+				"Sabine",
+				"Ursula",
+				"Monika",
+				"Petra",
+				"Jana",
+				"Greta",
+				"Frieda",
+				"Charlotte",
+				"Johanna",
+			},
+			"name.first.male": []string{
+				"Lukas",
+				"Leon",
+				"Finn",
+				"Jonas",
+				"Paul",
+				"Felix",
+				"Maximilian",
+				"Elias",
+				"Noah",
+				"Ben",
+				"Thomas",
+				"Michael",
+				"Andreas",
+				"Stefan",
+				"Jürgen",
+				"Klaus",
+				"Wolfgang",
+				"Matthias",
+				"Tobias",
+				"Jörg",
+			},
+			"name.last": []string{
+				"Müller",
+				"Schmidt",
+				"Schneider",
+				"Fischer",
+				"Weber",
+				"Meyer",
+				"Wagner",
+				"Becker",
+				"Schulz",
+				"Hoffmann",
+				"Schäfer",
+				"Koch",
+				"Bauer",
+				"Richter",
+				"Klein",
+				"Wolf",
+				"Schröder",
+				"Neumann",
+				"Schwarz",
+				"Zimmermann",
+				"Braun",
+				"Krüger",
+				"Hofmann",
+				"Hartmann",
+				"Lange",
+				"Schmitt",
+				"Werner",
+				"Krause",
+				"Meier",
+				"Lehmann",
+			},
+			"city": []string{
+				"Berlin",
+				"Hamburg",
+				"München",
+				"Köln",
+				"Frankfurt am Main",
+				"Stuttgart",
+				"Düsseldorf",
+				"Leipzig",
+				"Dortmund",
+				"Essen",
+				"Bremen",
+				"Dresden",
+				"Hannover",
+				"Nürnberg",
+				"Duisburg",
+				"Bochum",
+				"Wuppertal",
+				"Bielefeld",
+				"Bonn",
+				"Münster",
+			},
+			"state": []string{
+				"Baden-Württemberg",
+				"Bayern",
+				"Berlin",
+				"Brandenburg",
+				"Bremen",
+				"Hamburg",
+				"Hessen",
+				"Mecklenburg-Vorpommern",
+				"Niedersachsen",
+				"Nordrhein-Westfalen",
+				"Rheinland-Pfalz",
+				"Saarland",
+				"Sachsen",
+				"Sachsen-Anhalt",
+				"Schleswig-Holstein",
+				"Thüringen",
+			},
+			"street.name": []string{
+				"Hauptstraße",
+				"Schulstraße",
+				"Gartenstraße",
+				"Bahnhofstraße",
+				"Dorfstraße",
+				"Bergstraße",
+				"Birkenweg",
+				"Lindenstraße",
+				"Kirchstraße",
+				"Waldstraße",
+				"Ringstraße",
+				"Schillerstraße",
+				"Goethestraße",
+				"Am Markt",
+				"Rosenweg",
+				"Friedhofstraße",
+			},
+			"country": []string{
+				"Deutschland",
+			},
+			"country.code": []string{
+				"DE",
+			},
+		},
+		formats: map[string]string{
+			"tax.id":  "DE#########",
+			"street":  "{{ street.name }} {{ int:1,150 }}",
+			"address": "{{ street }}, {{ zip }} {{ city }}",
+			"zip":     "#####",
+			"phone":   "+49 15# ########",
+		},
+		date: "02.01.2006",
+	},
+	"fr_FR": &Locale{
+		dict: map[string][]string{
+			"company.suffix": []string{
+				"SAS",
+				"SARL",
+				"SA",
+				"SNC",
+			},
+			"company.tld": []string{
+				"fr",
+				"com",
+			},
+			"name.first.female": []string{
+				"Camille",
+				"Léa",
+				"Manon",
+				"Chloé",
+				"Emma",
+				"Inès",
+				"Jade",
+				"Louise",
+				"Zoé",
+				"Juliette",
+				"Élise",
+				"Margaux",
+				"Céline",
+				"Nathalie",
+				"Sylvie",
+				"Isabelle",
+				"Brigitte",
+				"Amélie",
+				"Aurélie",
+				"Hélène",
+			},
+			"name.first.male": []string{
+				"Lucas",
+				"Hugo",
+				"Louis",
+				"Gabriel",
+				"Jules",
+				"Arthur",
+				"Raphaël",
+				"Nathan",
+				"Théo",
+				"Mathis",
+				"Jean",
+				"Pierre",
+				"François",
+				"Nicolas",
+				"Stéphane",
+				"Sébastien",
+				"Jérôme",
+				"Benoît",
+				"Étienne",
+				"Olivier",
+			},
+			"name.last": []string{
+				"Martin",
+				"Bernard",
+				"Dubois",
+				"Thomas",
+				"Robert",
+				"Richard",
+				"Petit",
+				"Durand",
+				"Leroy",
+				"Moreau",
+				"Simon",
+				"Laurent",
+				"Lefèvre",
+				"Michel",
+				"Garcia",
+				"David",
+				"Bertrand",
+				"Roux",
+				"Vincent",
+				"Fournier",
+				"Morel",
+				"Girard",
+				"André",
+				"Lefebvre",
+				"Mercier",
+				"Dupont",
+				"Lambert",
+				"Bonnet",
+				"François",
+				"Martinez",
+			},
+			"city": []string{
+				"Paris",
+				"Marseille",
+				"Lyon",
+				"Toulouse",
+				"Nice",
+				"Nantes",
+				"Montpellier",
+				"Strasbourg",
+				"Bordeaux",
+				"Lille",
+				"Rennes",
+				"Reims",
+				"Saint-Étienne",
+				"Toulon",
+				"Le Havre",
+				"Grenoble",
+				"Dijon",
+				"Angers",
+				"Nîmes",
+				"Villeurbanne",
+			},
+			"state": []string{
+				"Auvergne-Rhône-Alpes",
+				"Bourgogne-Franche-Comté",
+				"Bretagne",
+				"Centre-Val de Loire",
+				"Corse",
+				"Grand Est",
+				"Hauts-de-France",
+				"Île-de-France",
+				"Normandie",
+				"Nouvelle-Aquitaine",
+				"Occitanie",
+				"Pays de la Loire",
+				"Provence-Alpes-Côte d'Azur",
+			},
+			"street.name": []string{
+				"rue de la Paix",
+				"rue Victor Hugo",
+				"avenue des Champs-Élysées",
+				"boulevard Saint-Germain",
+				"rue de la République",
+				"place de la Mairie",
+				"rue du Moulin",
+				"avenue Jean Jaurès",
+				"rue Pasteur",
+				"chemin des Vignes",
+				"rue de l’Église",
+				"allée des Tilleuls",
+			},
+			"country": []string{
+				"France",
+			},
+			"country.code": []string{
+				"FR",
+			},
+		},
+		formats: map[string]string{
+			"tax.id":  "FR## #########",
+			"street":  "{{ int:1,150 }} {{ street.name }}",
+			"address": "{{ street }}, {{ zip }} {{ city }}",
+			"zip":     "#####",
+			"phone":   "+33 6 ## ## ## ##",
+		},
+		date: "02/01/2006",
+	},
+	"ja_JP": &Locale{
+		dict: map[string][]string{
+			"company.suffix": []string{
+				"株式会社",
+				"合同会社",
+				"有限会社",
+			},
+			"company.tld": []string{
+				"co.jp",
+				"jp",
+			},
+			"name.first.female": []string{
+				"陽菜",
+				"結愛",
+				"葵",
+				"凛",
+				"芽依",
+				"さくら",
+				"美咲",
+				"愛子",
+				"由美",
+				"恵子",
+				"花子",
+				"優子",
+				"真由美",
+				"明美",
+				"彩",
+				"七海",
+				"結衣",
+				"美月",
+				"千尋",
+				"舞",
+			},
+			"name.first.male": []string{
+				"蓮",
+				"湊",
+				"陽翔",
+				"大翔",
+				"悠真",
+				"樹",
+				"颯太",
+				"翔太",
+				"拓海",
+				"健太",
+				"大輔",
+				"誠",
+				"浩",
+				"隆",
+				"直樹",
+				"和也",
+				"翼",
+				"亮",
+				"勇気",
+				"健一",
+			},
+			"name.last": []string{
+				"佐藤",
+				"鈴木",
+				"高橋",
+				"田中",
+				"伊藤",
+				"渡辺",
+				"山本",
+				"中村",
+				"小林",
+				"加藤",
+				"吉田",
+				"山田",
+				"佐々木",
+				"山口",
+				"松本",
+				"井上",
+				"木村",
+				"林",
+				"斎藤",
+				"清水",
+				"山崎",
+				"森",
+				"池田",
+				"橋本",
+				"阿部",
+				"石川",
+				"山下",
+				"中島",
+				"石井",
+				"小川",
+			},
+			"city": []string{
+				"千代田区",
+				"中央区",
+				"港区",
+				"新宿区",
+				"渋谷区",
+				"横浜市",
+				"大阪市",
+				"名古屋市",
+				"札幌市",
+				"福岡市",
+				"神戸市",
+				"京都市",
+				"川崎市",
+				"さいたま市",
+				"広島市",
+				"仙台市",
+				"千葉市",
+				"北九州市",
+				"堺市",
+				"新潟市",
+			},
+			"state": []string{
+				"東京都",
+				"北海道",
+				"大阪府",
+				"京都府",
+				"神奈川県",
+				"愛知県",
+				"埼玉県",
+				"千葉県",
+				"兵庫県",
+				"福岡県",
+				"静岡県",
+				"広島県",
+				"宮城県",
+				"新潟県",
+				"長野県",
+				"沖縄県",
+			},
+			"country": []string{
+				"日本",
+			},
+			"country.code": []string{
+				"JP",
+			},
+		},
+		formats: map[string]string{
+			"tax.id":  "T#############",
+			"street":  "{{ int:1,9 }}-{{ int:1,30 }}-{{ int:1,20 }}",
+			"address": "〒{{ zip }} {{ state }}{{ city }}{{ street }}",
+			"zip":     "###-####",
+			"phone":   "+81 90-####-####",
+		},
+		date:   "2006/01/02",
+		family: true,
+	},
+}
+
+func init() {
+	for _, l := range locales {
+		if l.dict == nil {
+			continue
+		}
+		if _, ok := l.dict["name.first"]; !ok {
+			l.dict["name.first"] = append(l.dict["name.first.female"], l.dict["name.first.male"]...)
+		}
+	}
+}
+
+// Get `p` from the locale, reports whether it has `p`.
+func (l *Locale) get(g *Generator, p string) (string, bool, error) {
+	if f, ok := l.formats[p]; ok {
+		s, err := g.Compile(f).Render(g, nil)
+		return digits(g, s), true, err
+	}
+
+	if list, ok := l.dict[p]; ok {
+		return list[g.rand.Intn(len(list))], true, nil
+	}
+
+	return "", false, nil
+}
+
+// Replace each "#" in `s` with a random digit.
+func digits(g *Generator, s string) string {
+	if !strings.Contains(s, "#") {
+		return s
+	}
+
+	b := []byte(s)
+	for i, c := range b {
+		if c == '#' {
+			b[i] = '0' + byte(g.rand.Intn(10))
+		}
+	}
+
+	return string(b)
+}
+
+// SetLocale sets the locales of the generator, each value is
+// generated in one of them, e.g "de_DE", "ja_JP" or "ar_EG".
+func (g *Generator) SetLocale(names ...string) error {
+	all := make([]*Locale, len(names))
+
+	for i, name := range names {
+		l, ok := locales[name]
+		if !ok {
+			return fmt.Errorf("unknown locale %q, expected one of %s", name, strings.Join(Locales(), ", "))
+		}
+		all[i] = l
+	}
+
+	g.locales = all
+	return nil
+}
+
+// SetLocale sets the locales of the default generator.
+func SetLocale(names ...string) error {
+	return gen.SetLocale(names...)
+}
+
+// Locales returns the names of all locales.
+func Locales() []string {
+	ret := make([]string, 0, len(locales))
+	for name := range locales {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,204 @@
+package phony
+
+import "strconv"
+import "strings"
+import "time"
+import "fmt"
+
+// Entities are records of related fields, `{{ person.email }}`
+// is the email of the person of the current record.
+var entities = make(map[string]func(g *Generator) (map[string]string, error))
+
+// Person fields.
+var personFields = []string{
+	"name",
+	"first",
+	"last",
+	"gender",
+	"username",
+	"email",
+	"birthdate",
+	"age",
+	"phone",
+	"street",
+	"city",
+	"state",
+	"zip",
+	"country",
+	"address",
+	"job",
+}
+
+func init() {
+	entities["person"] = person
+	for _, f := range personFields {
+		gens["person."+f] = field("person", f)
+	}
+}
+
+// Return a generator of field `f` of `entity`.
+func field(entity, f string) func(g *Generator, args []string) (string, error) {
+	return func(g *Generator, args []string) (string, error) {
+		e, err := g.entity(entity)
+		return e[f], err
+	}
+}
+
+// Return `name` of the current record, generating it once.
+func (g *Generator) entity(name string) (map[string]string, error) {
+	if e, ok := g.record[name]; ok {
+		return e, nil
+	}
+
+	e, err := entities[name](g)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.record != nil {
+		g.record[name] = e
+	}
+
+	return e, nil
+}
+
+// Generate a person, the name, gender and email agree, the age
+// matches the birthdate and the phone and address are in the
+// locale of the name.
+func person(g *Generator) (map[string]string, error) {
+	g = g.localized()
+	p := make(map[string]string)
+
+	p["gender"] = "female"
+	if g.rand.Intn(2) == 0 {
+		p["gender"] = "male"
+	}
+
+	p["first"], _ = g.Get("name.first." + p["gender"])
+	p["last"], _ = g.Get("name.last")
+	p["name"] = p["first"] + " " + p["last"]
+	if g.locale != nil && g.locale.family {
+		p["name"] = p["last"] + " " + p["first"]
+	}
+
+	p["username"] = username(g, p["first"], p["last"])
+	domain, _ := g.Get("domain")
+	p["email"] = p["username"] + "@" + domain
+
+	now := g.now().UTC()
+	birth := now.AddDate(-81, 0, 0).Add(time.Duration(g.rand.Int63n(int64(63*365*24*time.Hour))) + 24*time.Hour)
+	p["birthdate"] = birth.Format("2006-01-02")
+	p["age"] = strconv.Itoa(age(birth, now))
+
+	p["phone"], _ = g.Get("phone")
+	p["job"], _ = g.Get("job.title")
+
+	if err := place(g, p); err != nil {
+		return nil, fmt.Errorf("person: %s", err)
+	}
+
+	return p, nil
+}
+
+// Set the street, city, state, zip, country and
+// the address formatted from them in `e`.
+func place(g *Generator, e map[string]string) error {
+	e["city"], _ = g.Get("city")
+	e["zip"], _ = g.Get("zip")
+	e["street"], _ = g.Get("street")
+	e["country"], _ = g.Get("country")
+
+	code := ""
+	e["state"], code = state(g)
+
+	a := *g
+	a.locale = &Locale{
+		dict: map[string][]string{
+			"street":     {e["street"]},
+			"city":       {e["city"]},
+			"state":      {e["state"]},
+			"state.code": {code},
+			"zip":        {e["zip"]},
+		},
+	}
+
+	if g.locale != nil {
+		if f, ok := g.locale.formats["address"]; ok {
+			a.locale.formats = map[string]string{"address": f}
+		}
+	}
+
+	var err error
+	e["address"], err = a.Get("address")
+	return err
+}
+
+// Return a state and its code, the code is
+// empty when the locale has no codes.
+func state(g *Generator) (string, string) {
+	if g.locale != nil && g.locale.dict["state"] != nil {
+		s, _ := g.Get("state")
+		return s, ""
+	}
+
+	states, codes := g.set.dict["state"], g.set.dict["state.code"]
+	i := g.rand.Intn(len(states))
+	return states[i], codes[i]
+}
+
+// Return the age at `now` of someone born at `birth`.
+func age(birth, now time.Time) int {
+	n := now.Year() - birth.Year()
+	if now.Month() < birth.Month() || now.Month() == birth.Month() && now.Day() < birth.Day() {
+		n--
+	}
+	return n
+}
+
+// Return a username derived from `first` and `last`, names
+// that are not latin fall back to a random username.
+func username(g *Generator, first, last string) string {
+	a, b := ascii(first), ascii(last)
+	if a == "" || b == "" {
+		s, _ := g.Get("username")
+		return s
+	}
+
+	switch g.rand.Intn(4) {
+	case 0:
+		return a + "." + b
+	case 1:
+		return a[:1] + b
+	case 2:
+		return a + "_" + b
+	default:
+		return a + b + strconv.Itoa(1+g.rand.Intn(99))
+	}
+}
+
+// Latin letters with diacritics.
+var latin = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+	"à", "a", "â", "a", "á", "a", "ç", "c",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i", "í", "i", "ô", "o", "ó", "o",
+	"ù", "u", "û", "u", "ú", "u", "ñ", "n",
+)
+
+// Return `s` as lowercase ascii letters, empty when it isn't latin.
+func ascii(s string) string {
+	s = latin.Replace(strings.ToLower(s))
+	b := make([]byte, 0, len(s))
+
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z':
+			b = append(b, byte(c))
+		case c == ' ' || c == '-' || c == '\'':
+		default:
+			return ""
+		}
+	}
+
+	return string(b)
+}
@@ -1,6 +1,8 @@
 package phony
 
 import "math/rand"
+import "sync"
+import "time"
 
 // Default generator.
 var gen = New(&Dataset{
@@ -16,12 +18,51 @@ type Dataset struct {
 
 // Generator structure.
 type Generator struct {
-	set *Dataset
+	set     *Dataset
+	rand    *rand.Rand
+	secret  []byte
+	locales []*Locale
+	locale  *Locale
+	record  map[string]map[string]string
+	epoch   time.Time
 }
 
+// Clock of generators, replaced in tests.
+var clock = time.Now
+
 // Initialize Generator with `dataset`.
 func New(set *Dataset) *Generator {
-	return &Generator{set}
+	src := &source{src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)}
+	return &Generator{set: set, rand: rand.New(src)}
+}
+
+// Now returns the current time, keyed generators
+// return a fixed time so that their values are stable.
+func (g *Generator) now() time.Time {
+	if !g.epoch.IsZero() {
+		return g.epoch
+	}
+	return clock()
+}
+
+// Seed the generator, making the data it
+// generates reproducible.
+func (g *Generator) Seed(seed int64) {
+	g.rand.Seed(seed)
+}
+
+// Fork returns a generator over the same dataset, secret
+// and locales with its own random source.
+func (g *Generator) Fork() *Generator {
+	f := New(g.set)
+	f.secret = g.secret
+	f.locales = g.locales
+	return f
+}
+
+// Rand returns the random source of the generator.
+func (g *Generator) Rand() *rand.Rand {
+	return g.rand
 }
 
 // Get `path`.
@@ -30,26 +71,59 @@ func (g *Generator) Get(p string) (string, error) {
 }
 
 // Get `path`.
+//
+// With locales each value is generated in one of them,
+// paths it generates are in the same locale.
 func (g *Generator) GetWithArgs(p string, args []string) (string, error) {
 	gens := g.set.gens
 	dict := g.set.dict
 
-	for k, f := range gens {
-		if k == p {
-			return f(g, args)
-		}
+	if g.locale == nil && len(g.locales) > 0 {
+		return g.localized().GetWithArgs(p, args)
 	}
 
-	for k, list := range dict {
-		if k == p {
-			i := rand.Intn(len(list))
-			return list[i], nil
+	if g.locale != nil {
+		if s, ok, err := g.locale.get(g, p); ok {
+			return s, err
 		}
 	}
 
+	if f, ok := gens[p]; ok {
+		return f(g, args)
+	}
+
+	if list, ok := dict[p]; ok {
+		i := g.rand.Intn(len(list))
+		return list[i], nil
+	}
+
 	return "", nil
 }
 
+// Return a generator in one of the locales.
+func (g *Generator) localized() *Generator {
+	if g.locale != nil || len(g.locales) == 0 {
+		return g
+	}
+
+	l := *g
+	l.locale = g.locales[0]
+	if len(g.locales) > 1 {
+		l.locale = g.locales[g.rand.Intn(len(g.locales))]
+	}
+
+	return &l
+}
+
+// Record returns a generator of a single record, entities
+// such as `person` are generated once per record so that
+// `{{ person.name }}` and `{{ person.email }}` agree.
+func (g *Generator) Record() *Generator {
+	r := *g
+	r.record = make(map[string]map[string]string)
+	return &r
+}
+
 // List all paths.
 func (g *Generator) List() []string {
 	gens := g.set.gens
@@ -61,7 +135,9 @@ func (g *Generator) List() []string {
 	}
 
 	for k, _ := range dict {
-		ret = append(ret, k)
+		if _, ok := gens[k]; !ok {
+			ret = append(ret, k)
+		}
 	}
 
 	return ret
@@ -81,3 +157,50 @@ func GetWithArgs(path string, args []string) (string, error) {
 func List() []string {
 	return gen.List()
 }
+
+// Record returns a record of the default generator.
+func Record() *Generator {
+	return gen.Record()
+}
+
+// Seed the default generator.
+func Seed(seed int64) {
+	gen.Seed(seed)
+}
+
+// Fork the default generator.
+func Fork() *Generator {
+	return gen.Fork()
+}
+
+// Rand returns the random source of the default generator.
+func Rand() *rand.Rand {
+	return gen.Rand()
+}
+
+// Source structure, safe for concurrent use.
+type source struct {
+	sync.Mutex
+	src rand.Source64
+}
+
+// Int63 implementation.
+func (s *source) Int63() int64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.src.Int63()
+}
+
+// Uint64 implementation.
+func (s *source) Uint64() uint64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.src.Uint64()
+}
+
+// Seed implementation.
+func (s *source) Seed(seed int64) {
+	s.Lock()
+	defer s.Unlock()
+	s.src.Seed(seed)
+}
@@ -1,7 +1,10 @@
 package phony
 
 import "github.com/bmizerany/assert"
+import "strings"
+import "strconv"
 import "testing"
+import "time"
 
 func TestGet(t *testing.T) {
 	a, _ := Get("name")
@@ -21,3 +24,231 @@ func TestAll(t *testing.T) {
 		assert.NotEqual(t, a, "")
 	}
 }
+
+func TestCompile(t *testing.T) {
+	s, err := Compile("{{ domain.tld }}").Execute()
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, "", s)
+	assert.NotEqual(t, "{{ domain.tld }}", s)
+}
+
+func TestRanges(t *testing.T) {
+	s, err := Compile("{{ int:5,5 }} {{ float:1,1,2 }} {{ date:2020-01-02,2020-01-02 }}").Execute()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "5 1.00 2020-01-02", s)
+
+	s, err = Compile("{{ datetime:2020-01-02T03:04:05Z,2020-01-02T03:04:05Z }}").Execute()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "2020-01-02T03:04:05Z", s)
+
+	_, err = GetWithArgs("int", []string{"2", "1"})
+	assert.NotEqual(t, nil, err)
+
+	bounds := [][]string{
+		{"0", "9223372036854775807"},
+		{"-5", "9223372036854775807"},
+		{"-9223372036854775808", "9223372036854775807"},
+		{"-9223372036854775808", "0"},
+		{"9223372036854775807", "9223372036854775807"},
+	}
+
+	for _, b := range bounds {
+		min, _ := strconv.ParseInt(b[0], 10, 64)
+		max, _ := strconv.ParseInt(b[1], 10, 64)
+		for i := 0; i < 100; i++ {
+			s, err := GetWithArgs("int", b)
+			assert.Equal(t, nil, err)
+			n, err := strconv.ParseInt(s, 10, 64)
+			assert.Equal(t, nil, err)
+			assert.T(t, n >= min && n <= max, b, n)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		s, err := GetWithArgs("date", []string{"1000-01-01", "2900-01-01"})
+		assert.Equal(t, nil, err)
+		assert.T(t, s >= "1000-01-01" && s <= "2900-01-01", s)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	tmpl := Compile("{{ name }} {{ uuid }} {{ int:0,1000000 }}")
+
+	Seed(7)
+	a, _ := tmpl.Execute()
+	Seed(7)
+	b, _ := tmpl.Execute()
+	assert.Equal(t, a, b)
+}
+
+func TestVars(t *testing.T) {
+	s, err := Compile("{{ .a }}-{{ .b.c }}").Render(nil, Vars{"a": "1", "b.c": "2"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "1-2", s)
+
+	_, err = Compile("{{ .missing }}").Execute()
+	assert.NotEqual(t, nil, err)
+}
+
+func TestTemplates(t *testing.T) {
+	tmpl, err := ParseTemplates("default", "--- pageview 80\npv {{ .template }}\n--- click 15\nclick\n--- purchase 5\nbuy\n")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"pageview", "click", "purchase"}, tmpl.Names())
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		s, err := tmpl.Render(nil, nil)
+		assert.Equal(t, nil, err)
+		counts[s]++
+	}
+
+	assert.Equal(t, 3, len(counts))
+	assert.T(t, counts["pv pageview\n"] > 7500 && counts["pv pageview\n"] < 8500, counts)
+	assert.T(t, counts["buy\n"] > 300 && counts["buy\n"] < 700, counts)
+
+	tmpl, err = ParseTemplates("stdin", "{{ .template }}\n")
+	assert.Equal(t, nil, err)
+	s, _ := tmpl.Render(nil, nil)
+	assert.Equal(t, "stdin\n", s)
+
+	_, err = ParseTemplates("x", "--- a 0\n")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestKeyed(t *testing.T) {
+	g := Fork()
+	g.SetSecret([]byte("a"))
+
+	a, err := g.GetKeyed("uuid", "alice@corp.com")
+	assert.Equal(t, nil, err)
+	b, _ := g.Fork().GetKeyed("uuid", "alice@corp.com")
+	assert.Equal(t, a, b)
+
+	c, _ := g.GetKeyed("uuid", "bob@corp.com")
+	assert.NotEqual(t, a, c)
+
+	g.SetSecret([]byte("b"))
+	d, _ := g.GetKeyed("uuid", "alice@corp.com")
+	assert.NotEqual(t, a, d)
+
+	e, _ := g.GetKeyed("email", "alice@corp.com")
+	s, err := g.Compile("{{ email#.in.email }}").Render(nil, Vars{"in.email": "alice@corp.com"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, e, s)
+
+	f, _ := g.GetKeyedWithArgs("int", []string{"1", "1000000"}, "x")
+	s, _ = g.Compile("{{ int:1,1000000#x }}").Execute()
+	assert.Equal(t, f, s)
+}
+
+func TestKeyedClock(t *testing.T) {
+	defer func() { clock = time.Now }()
+	g := Fork()
+	tpl := g.Compile("{{ date#x }} {{ ksuid#x }} {{ person.age#x }} {{ now.utc#x }}")
+
+	clock = func() time.Time { return time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC) }
+	a, err := tpl.Execute()
+	assert.Equal(t, nil, err)
+
+	clock = func() time.Time { return time.Date(2040, 6, 1, 0, 0, 0, 0, time.UTC) }
+	b, err := tpl.Execute()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, a, b)
+
+	c, _ := g.Get("now.utc")
+	assert.Equal(t, "2040-06-01T00:00:00Z", c)
+}
+
+func TestLocale(t *testing.T) {
+	g := Fork()
+	assert.NotEqual(t, nil, g.SetLocale("xx_XX"))
+	assert.Equal(t, nil, g.SetLocale("ja_JP"))
+
+	phone, _ := g.Get("phone")
+	assert.T(t, strings.HasPrefix(phone, "+81 90-"), phone)
+	assert.T(t, !strings.Contains(phone, "#"), phone)
+
+	address, _ := g.Get("address")
+	assert.T(t, strings.HasPrefix(address, "〒"), address)
+
+	name, _ := g.Get("name")
+	last := strings.Split(name, " ")[0]
+	assert.T(t, contains(locales["ja_JP"].dict["name.last"], last), name)
+
+	date, _ := g.GetWithArgs("date.local", []string{"2020-03-04", "2020-03-04"})
+	assert.Equal(t, "2020/03/04", date)
+
+	id, _ := g.Get("uuid")
+	assert.Equal(t, 36, len(id))
+
+	assert.Equal(t, nil, g.SetLocale("de_DE", "ar_EG"))
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		s, _ := g.Get("country.code")
+		seen[s] = true
+	}
+	assert.Equal(t, map[string]bool{"DE": true, "EG": true}, seen)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPerson(t *testing.T) {
+	s, err := Compile("{{ person.first }} {{ person.last }}|{{ person.name }}|{{ person.age }}|{{ person.birthdate }}").Execute()
+	assert.Equal(t, nil, err)
+
+	parts := strings.Split(s, "|")
+	assert.Equal(t, parts[0], parts[1])
+
+	birth, err := time.Parse("2006-01-02", parts[3])
+	assert.Equal(t, nil, err)
+	assert.Equal(t, strconv.Itoa(age(birth, time.Now().UTC())), parts[2])
+
+	g := Fork()
+	g.SetLocale("de_DE")
+	p, err := g.Record().entity("person")
+	assert.Equal(t, nil, err)
+	assert.T(t, contains(locales["de_DE"].dict["name.first."+p["gender"]], p["first"]), p)
+	assert.T(t, strings.HasPrefix(p["phone"], "+49"), p)
+	assert.T(t, strings.Contains(p["address"], p["zip"]+" "+p["city"]), p)
+	assert.T(t, strings.HasPrefix(p["email"], p["username"]+"@"), p)
+	assert.T(t, strings.Contains(p["username"], ascii(p["last"])), p)
+
+	a, _ := g.GetKeyed("person.name", "1")
+	b, _ := g.GetKeyed("person.email", "1")
+	q, _ := g.Keyed("person\x00" + "1").Record().entity("person")
+	assert.Equal(t, q["name"], a)
+	assert.Equal(t, q["email"], b)
+}
+
+func TestCompany(t *testing.T) {
+	g := Fork()
+	g.SetLocale("de_DE")
+
+	for i := 0; i < 100; i++ {
+		c, err := g.Record().entity("company")
+		assert.Equal(t, nil, err)
+
+		assert.T(t, strings.HasPrefix(strings.ToLower(c["name"]), strings.Split(c["domain"], ".")[0]), c)
+		assert.Equal(t, "https://www."+c["domain"], c["website"])
+		assert.T(t, strings.HasPrefix(c["taxid"], "DE"), c)
+		assert.T(t, strings.Contains(c["address"], c["zip"]+" "+c["city"]), c)
+
+		founded, _ := strconv.Atoi(c["founded"])
+		employees, _ := strconv.Atoi(c["employees"])
+		age := time.Now().UTC().Year() - founded
+		assert.T(t, age >= 1 && age <= 120, c)
+		assert.T(t, employees >= 1 && employees <= 10*age*age+1, c)
+	}
+
+	s, err := Compile("{{ company.name }}|{{ company.name }}").Execute()
+	assert.Equal(t, nil, err)
+	parts := strings.Split(s, "|")
+	assert.Equal(t, parts[0], parts[1])
+}
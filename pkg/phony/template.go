@@ -0,0 +1,99 @@
+package phony
+
+import "strings"
+import "regexp"
+import "fmt"
+
+// Template expression.
+var expr = regexp.MustCompile(`({{ *(\.?[a-zA-Z0-9_]+(\.[a-zA-Z0-9_]+)*(\:([a-zA-Z0-9,.:+\-]+))?(#\.?[a-zA-Z0-9_@.+\-]+)?) *}})`)
+
+// Vars are template variables, `{{ .name }}`
+// is replaced with the value of "name".
+type Vars map[string]string
+
+// Template structure.
+type Template struct {
+	gen  *Generator
+	text string
+}
+
+// Compile `text` into a template bound to the generator.
+func (g *Generator) Compile(text string) *Template {
+	return &Template{gen: g, text: text}
+}
+
+// Execute the template, replacing each `{{ path:args }}`
+// with generated data. `{{ path#key }}` generates the same
+// data for the same key, which may be a variable such as
+// `{{ email#.in.email }}`, see Generator.GetKeyed.
+func (t *Template) Execute() (string, error) {
+	return t.ExecuteWith(t.gen)
+}
+
+// ExecuteWith executes the template with generator `g`,
+// a nil `g` uses the generator the template is bound to.
+func (t *Template) ExecuteWith(g *Generator) (string, error) {
+	return t.Render(g, nil)
+}
+
+// Render executes the template with generator `g` and `vars`.
+func (t *Template) Render(g *Generator, vars Vars) (string, error) {
+	var err error
+
+	if g == nil {
+		g = t.gen
+	}
+
+	if g.record == nil {
+		g = g.Record()
+	}
+
+	ret := expr.ReplaceAllStringFunc(t.text, func(s string) string {
+		if err != nil {
+			return ""
+		}
+
+		call := strings.Trim(s[2:len(s)-2], " ")
+		if strings.HasPrefix(call, ".") {
+			v, ok := vars[call[1:]]
+			if !ok {
+				err = fmt.Errorf("unknown variable %s", call)
+			}
+			return v
+		}
+
+		call, key, keyed := strings.Cut(call, "#")
+		if keyed && strings.HasPrefix(key, ".") {
+			v, ok := vars[key[1:]]
+			if !ok {
+				err = fmt.Errorf("unknown variable %s", key)
+			}
+			key = v
+		}
+
+		parts := strings.SplitN(call, ":", 2)
+		var args []string = nil
+		if len(parts) == 2 {
+			args = strings.Split(parts[1], ",")
+		}
+
+		var data string
+		var e error
+		if keyed {
+			data, e = g.GetKeyedWithArgs(parts[0], args, key)
+		} else {
+			data, e = g.GetWithArgs(parts[0], args)
+		}
+		if e != nil {
+			err = e
+		}
+		return data
+	})
+
+	return ret, err
+}
+
+// Compile `text` with the default generator.
+func Compile(text string) *Template {
+	return gen.Compile(text)
+}
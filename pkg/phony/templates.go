@@ -0,0 +1,104 @@
+package phony
+
+import "strconv"
+import "strings"
+import "regexp"
+import "fmt"
+
+// Template separator, "--- name [weight]".
+var separator = regexp.MustCompile(`^--- *([a-zA-Z0-9_.\-]+)(?: +([0-9.]+))? *$`)
+
+// Templates structure, picks one of several
+// named templates by weight for each record.
+type Templates struct {
+	names     []string
+	templates []*Template
+	weights   []float64
+	total     float64
+}
+
+// ParseTemplates parses `text` holding templates separated by
+// "--- name weight" lines, a missing weight is 1. Text before
+// the first separator is a template called `name`.
+func ParseTemplates(name, text string) (*Templates, error) {
+	t := &Templates{}
+	body, weight := "", 1.0
+
+	add := func() {
+		if strings.TrimSpace(body) != "" || len(t.names) > 0 {
+			t.Add(name, weight, Compile(body))
+		}
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		m := separator.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if m == nil {
+			body += line
+			continue
+		}
+
+		add()
+		name, body, weight = m[1], "", 1
+
+		if m[2] != "" {
+			w, err := strconv.ParseFloat(m[2], 64)
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("%s: invalid weight %q", name, m[2])
+			}
+			weight = w
+		}
+	}
+
+	add()
+
+	if len(t.names) == 0 {
+		return nil, fmt.Errorf("no templates")
+	}
+
+	return t, nil
+}
+
+// Add template `tmpl` called `name` with `weight`.
+func (t *Templates) Add(name string, weight float64, tmpl *Template) {
+	t.names = append(t.names, name)
+	t.templates = append(t.templates, tmpl)
+	t.weights = append(t.weights, weight)
+	t.total += weight
+}
+
+// Merge adds all templates of `o`.
+func (t *Templates) Merge(o *Templates) {
+	for i, name := range o.names {
+		t.Add(name, o.weights[i], o.templates[i])
+	}
+}
+
+// Names returns the names of all templates.
+func (t *Templates) Names() []string {
+	return t.names
+}
+
+// Render picks a template with `g`, nil for the default generator,
+// and renders it with `vars` and the chosen template name as
+// `{{ .template }}`.
+func (t *Templates) Render(g *Generator, vars Vars) (string, error) {
+	if g == nil {
+		g = gen
+	}
+
+	i := 0
+	if len(t.templates) > 1 {
+		n := g.rand.Float64() * t.total
+		for i < len(t.weights)-1 && n >= t.weights[i] {
+			n -= t.weights[i]
+			i++
+		}
+	}
+
+	all := Vars{"template": t.names[i]}
+	for k, v := range vars {
+		all[k] = v
+	}
+
+	return t.templates[i].Render(g, all)
+}
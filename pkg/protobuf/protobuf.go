@@ -0,0 +1,305 @@
+package protobuf
+
+import "google.golang.org/protobuf/reflect/protoreflect"
+import "google.golang.org/protobuf/types/descriptorpb"
+import "google.golang.org/protobuf/reflect/protodesc"
+import "google.golang.org/protobuf/types/dynamicpb"
+import "github.com/yields/phony/pkg/schema"
+import "github.com/yields/phony/pkg/phony"
+import "google.golang.org/protobuf/proto"
+import "github.com/bufbuild/protocompile"
+import "path/filepath"
+import "io/ioutil"
+import "strconv"
+import "strings"
+import "context"
+import "time"
+import "fmt"
+
+// Maximum depth of nested messages, repeated fields
+// and singular messages stop at this depth.
+const maxDepth = 3
+
+// Generator structure.
+type Generator struct {
+	desc    protoreflect.MessageDescriptor
+	mapping map[string]*phony.Template
+}
+
+// Load returns the descriptor of `message` from the .proto
+// source or the compiled FileDescriptorSet at `path`.
+func Load(path, message string) (protoreflect.MessageDescriptor, error) {
+	if filepath.Ext(path) == ".proto" {
+		r := &protocompile.SourceResolver{ImportPaths: []string{filepath.Dir(path)}}
+		return compile(r, filepath.Base(path), message)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("protobuf: %s: %s", path, err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf: %s", err)
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(message))
+	if err != nil {
+		return nil, fmt.Errorf("protobuf: %s: %s", message, err)
+	}
+
+	return asMessage(d)
+}
+
+// Compile `file` with resolver `r`, returning `message`.
+func compile(r *protocompile.SourceResolver, file, message string) (protoreflect.MessageDescriptor, error) {
+	c := protocompile.Compiler{Resolver: protocompile.WithStandardImports(r)}
+
+	files, err := c.Compile(context.Background(), file)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf: %s", err)
+	}
+
+	d := files[0].FindDescriptorByName(protoreflect.FullName(message))
+	if d == nil {
+		return nil, fmt.Errorf("protobuf: %s: message not found", message)
+	}
+
+	return asMessage(d)
+}
+
+// Return `d` as a message descriptor.
+func asMessage(d protoreflect.Descriptor) (protoreflect.MessageDescriptor, error) {
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("protobuf: %s is not a message", d.FullName())
+	}
+	return md, nil
+}
+
+// New returns a generator of `desc` messages, `mapping` maps
+// dotted field paths such as "user.email" to a generator path
+// or a template, other fields are filled by their name and kind.
+func New(desc protoreflect.MessageDescriptor, mapping map[string]string) *Generator {
+	g := &Generator{desc: desc, mapping: make(map[string]*phony.Template)}
+
+	for field, v := range mapping {
+		if !strings.Contains(v, "{{") {
+			v = "{{ " + v + " }}"
+		}
+		g.mapping[field] = phony.Compile(v)
+	}
+
+	return g
+}
+
+// Generate a message.
+func (g *Generator) Generate() (proto.Message, error) {
+	m := dynamicpb.NewMessage(g.desc)
+	if err := g.fill(m, "", 0); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Fill all fields of `m`, `prefix` is the dotted path of `m`.
+func (g *Generator) fill(m protoreflect.Message, prefix string, depth int) error {
+	if ok, err := wellKnown(m); ok || err != nil {
+		return err
+	}
+
+	fields := m.Descriptor().Fields()
+	oneofs := make(map[protoreflect.OneofDescriptor]protoreflect.FieldDescriptor)
+
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if o := fd.ContainingOneof(); o != nil && !o.IsSynthetic() {
+			if _, ok := oneofs[o]; !ok {
+				oneofs[o] = o.Fields().Get(phony.Rand().Intn(o.Fields().Len()))
+			}
+			if oneofs[o] != fd {
+				continue
+			}
+		}
+
+		path := prefix + string(fd.Name())
+
+		switch {
+		case fd.IsMap():
+			if depth >= maxDepth {
+				continue
+			}
+			mv := m.Mutable(fd).Map()
+			for n := 1 + phony.Rand().Intn(3); n > 0; n-- {
+				k, err := g.value(nil, fd.MapKey(), path, depth)
+				if err != nil {
+					return err
+				}
+				v, err := g.value(mv.NewValue, fd.MapValue(), path, depth)
+				if err != nil {
+					return err
+				}
+				mv.Set(k.MapKey(), v)
+			}
+		case fd.IsList():
+			if depth >= maxDepth {
+				continue
+			}
+			list := m.Mutable(fd).List()
+			for n := 1 + phony.Rand().Intn(3); n > 0; n-- {
+				v, err := g.value(list.NewElement, fd, path, depth)
+				if err != nil {
+					return err
+				}
+				list.Append(v)
+			}
+		case fd.Message() != nil && depth >= maxDepth:
+			continue
+		default:
+			alloc := func() protoreflect.Value { return m.NewField(fd) }
+			v, err := g.value(alloc, fd, path, depth)
+			if err != nil {
+				return err
+			}
+			m.Set(fd, v)
+		}
+	}
+
+	return nil
+}
+
+// Generate a value of `fd` at `path`, `alloc`
+// returns a new value for message fields.
+func (g *Generator) value(alloc func() protoreflect.Value, fd protoreflect.FieldDescriptor, path string, depth int) (protoreflect.Value, error) {
+	if fd.Message() != nil {
+		v := alloc()
+		if err := g.fill(v.Message(), path+".", depth+1); err != nil {
+			return v, err
+		}
+		return v, nil
+	}
+
+	if t, ok := g.mapping[path]; ok {
+		s, err := t.Execute()
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		v, err := parse(fd, s)
+		if err != nil {
+			return v, fmt.Errorf("protobuf: %s: %s", path, err)
+		}
+		return v, nil
+	}
+
+	return scalar(fd), nil
+}
+
+// Generate a scalar value of `fd` from its name and kind.
+func scalar(fd protoreflect.FieldDescriptor) protoreflect.Value {
+	name := string(fd.Name())
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(phony.Rand().Intn(2) == 0)
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		i := phony.Rand().Intn(values.Len())
+		if values.Len() > 1 && values.Get(i).Number() == 0 {
+			i = 1 + phony.Rand().Intn(values.Len()-1)
+		}
+		return protoreflect.ValueOfEnum(values.Get(i).Number())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(phony.Rand().Int31n(1000))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(phony.Rand().Int63n(1000))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(phony.Rand().Int31n(1000)))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(phony.Rand().Int63n(1000)))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f := phony.Rand().Float64() * 1000
+		if path := schema.Lookup(name); path == "latitude" || path == "longitude" {
+			s, _ := phony.Get(path)
+			f, _ = strconv.ParseFloat(s, 64)
+		}
+		if fd.Kind() == protoreflect.FloatKind {
+			return protoreflect.ValueOfFloat32(float32(f))
+		}
+		return protoreflect.ValueOfFloat64(f)
+	case protoreflect.BytesKind:
+		b := make([]byte, 8+phony.Rand().Intn(8))
+		phony.Rand().Read(b)
+		return protoreflect.ValueOfBytes(b)
+	default:
+		path := schema.Lookup(name)
+		if path == "" {
+			path = "id"
+		}
+		s, _ := phony.Get(path)
+		return protoreflect.ValueOfString(s)
+	}
+}
+
+// Parse `s` into a value of `fd`.
+func parse(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(s)), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.EnumKind:
+		if v := fd.Enum().Values().ByName(protoreflect.Name(s)); v != nil {
+			return protoreflect.ValueOfEnum(v.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	default:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	}
+}
+
+// Fill well-known types, reports whether `m` is one.
+func wellKnown(m protoreflect.Message) (bool, error) {
+	fields := m.Descriptor().Fields()
+
+	switch m.Descriptor().FullName() {
+	case "google.protobuf.Timestamp":
+		t := time.Now().Add(-time.Duration(phony.Rand().Int63n(int64(365 * 24 * time.Hour))))
+		m.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+		m.Set(fields.ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+		return true, nil
+	case "google.protobuf.Duration":
+		m.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(phony.Rand().Int63n(3600)))
+		return true, nil
+	case "google.protobuf.Any", "google.protobuf.Struct", "google.protobuf.Value",
+		"google.protobuf.ListValue", "google.protobuf.Empty", "google.protobuf.FieldMask":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,119 @@
+package protobuf
+
+import "google.golang.org/protobuf/reflect/protoreflect"
+import "google.golang.org/protobuf/reflect/protodesc"
+import "google.golang.org/protobuf/types/descriptorpb"
+import "google.golang.org/protobuf/types/dynamicpb"
+import "google.golang.org/protobuf/encoding/protojson"
+import "google.golang.org/protobuf/proto"
+import "github.com/bufbuild/protocompile"
+import "github.com/bmizerany/assert"
+import "path/filepath"
+import "io/ioutil"
+import "testing"
+import "os"
+
+var source = `
+syntax = "proto3";
+package shop;
+
+import "google/protobuf/timestamp.proto";
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+  STATUS_BANNED = 2;
+}
+
+message Address {
+  string country = 1;
+  double latitude = 2;
+}
+
+message User {
+  string id = 1;
+  string email = 2;
+  Status status = 3;
+  repeated string tags = 4;
+  Address address = 5;
+  google.protobuf.Timestamp created = 6;
+  map<string, int64> counters = 7;
+  int32 age = 8;
+  oneof contact {
+    string phone = 9;
+    string ipv4 = 10;
+  }
+}
+`
+
+func load(t *testing.T) protoreflect.MessageDescriptor {
+	r := &protocompile.SourceResolver{
+		Accessor: protocompile.SourceAccessorFromMap(map[string]string{"shop.proto": source}),
+	}
+	d, err := compile(r, "shop.proto", "shop.User")
+	assert.Equal(t, nil, err)
+	return d
+}
+
+func TestGenerate(t *testing.T) {
+	d := load(t)
+	g := New(d, map[string]string{
+		"id":              "uuid",
+		"age":             "{{ int:18,99 }}",
+		"address.country": "country.code",
+	})
+
+	for i := 0; i < 50; i++ {
+		m, err := g.Generate()
+		assert.Equal(t, nil, err)
+
+		b, err := proto.Marshal(m)
+		assert.Equal(t, nil, err)
+
+		out := dynamicpb.NewMessage(d)
+		assert.Equal(t, nil, proto.Unmarshal(b, out))
+		assert.T(t, proto.Equal(m, out))
+
+		fields := d.Fields()
+		assert.Equal(t, 36, len(out.Get(fields.ByName("id")).String()))
+		assert.NotEqual(t, protoreflect.EnumNumber(0), out.Get(fields.ByName("status")).Enum())
+		assert.T(t, out.Get(fields.ByName("tags")).List().Len() > 0)
+		assert.T(t, out.Has(fields.ByName("created")))
+		assert.T(t, out.Has(fields.ByName("phone")) != out.Has(fields.ByName("ipv4")))
+
+		age := out.Get(fields.ByName("age")).Int()
+		assert.T(t, age >= 18 && age <= 99)
+
+		addr := out.Get(fields.ByName("address")).Message()
+		assert.Equal(t, 2, len(addr.Get(addr.Descriptor().Fields().ByName("country")).String()))
+
+		_, err = protojson.Marshal(m)
+		assert.Equal(t, nil, err)
+	}
+}
+
+func TestLoadDescriptorSet(t *testing.T) {
+	d := load(t)
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, f := range []protoreflect.FileDescriptor{d.ParentFile().Imports().Get(0).FileDescriptor, d.ParentFile()} {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(f))
+	}
+
+	b, err := proto.Marshal(set)
+	assert.Equal(t, nil, err)
+
+	dir, err := ioutil.TempDir("", "phony")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "shop.pb")
+	assert.Equal(t, nil, ioutil.WriteFile(path, b, 0644))
+
+	md, err := Load(path, "shop.User")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, protoreflect.FullName("shop.User"), md.FullName())
+
+	_, err = Load(path, "shop.Status")
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,113 @@
+package rate
+
+import "math/rand"
+import "context"
+import "time"
+import "fmt"
+
+// Distributions of the gap between arrivals, as
+// multiples of the mean gap.
+var distributions = map[string]func(r *rand.Rand) float64{
+	"poisson": func(r *rand.Rand) float64 { return r.ExpFloat64() },
+	"uniform": func(r *rand.Rand) float64 { return 2 * r.Float64() },
+}
+
+// Arrivals structure, schedules records with random gaps
+// drawn from a distribution whose mean is the profile rate.
+type Arrivals struct {
+	profile Profile
+	gap     func(r *rand.Rand) float64
+	rand    *rand.Rand
+	start   time.Time
+	next    time.Time
+	idle    bool
+	ended   bool
+	lag     time.Duration
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration)
+}
+
+// NewArrivals returns arrivals of `p` with gaps from distribution
+// `dist`, "poisson" for exponential gaps or "uniform", drawn from `r`.
+func NewArrivals(p Profile, dist string, r *rand.Rand) (*Arrivals, error) {
+	gap, ok := distributions[dist]
+	if !ok {
+		return nil, fmt.Errorf("rate: unknown distribution %q", dist)
+	}
+
+	return &Arrivals{
+		profile: p,
+		gap:     gap,
+		rand:    r,
+		now:     time.Now,
+		sleep:   sleep,
+	}, nil
+}
+
+// Take blocks until the next arrival and returns the number of
+// records that arrived, more than one when the caller was slow,
+// arrivals more than a second late are dropped.
+//
+// Take returns 0 when the profile has ended or `ctx` is done.
+func (a *Arrivals) Take(ctx context.Context) int {
+	if a.start.IsZero() {
+		a.start = a.now()
+		a.next = a.start
+		a.schedule()
+	}
+
+	for !a.ended {
+		if wait := a.next.Sub(a.now()); wait > 0 {
+			a.sleep(ctx, wait)
+		}
+
+		if ctx.Err() != nil {
+			return 0
+		}
+
+		now := a.now()
+		if a.lag = now.Sub(a.next); a.lag < 0 {
+			a.lag = 0
+		}
+
+		if now.Sub(a.next) > time.Second {
+			a.next = now.Add(-time.Second)
+		}
+
+		n := 0
+		for !a.ended && !a.next.After(now) {
+			if !a.idle {
+				n++
+			}
+			a.schedule()
+		}
+
+		if n > 0 {
+			return n
+		}
+	}
+
+	return 0
+}
+
+// Lag returns how far behind schedule the last Take was.
+func (a *Arrivals) Lag() time.Duration {
+	return a.lag
+}
+
+// Schedule the next arrival, while the rate is
+// zero the profile is checked every 100ms.
+func (a *Arrivals) schedule() {
+	rate := a.profile.Rate(a.next.Sub(a.start))
+
+	switch {
+	case rate < 0:
+		a.ended = true
+	case rate == 0:
+		a.next = a.next.Add(100 * time.Millisecond)
+		a.idle = true
+	default:
+		a.next = a.next.Add(time.Duration(a.gap(a.rand) / rate * float64(time.Second)))
+		a.idle = false
+	}
+}
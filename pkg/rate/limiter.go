@@ -0,0 +1,92 @@
+package rate
+
+import "context"
+import "math"
+import "time"
+
+// Limiter structure, paces records with a token bucket
+// that fills at the rate of a profile.
+type Limiter struct {
+	profile Profile
+	start   time.Time
+	last    time.Time
+	tokens  float64
+	lag     time.Duration
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration)
+}
+
+// NewLimiter returns a limiter for `p`.
+func NewLimiter(p Profile) *Limiter {
+	return &Limiter{
+		profile: p,
+		now:     time.Now,
+		sleep:   sleep,
+	}
+}
+
+// Take blocks until at least one record is due and returns
+// the number of records to send, records that are late because
+// the caller was slow are sent at once, up to a second worth.
+//
+// Take returns 0 when the profile has ended or `ctx` is done.
+func (l *Limiter) Take(ctx context.Context) int {
+	if l.start.IsZero() {
+		l.start = l.now()
+		l.last = l.start
+		l.tokens = 1
+	}
+
+	for ctx.Err() == nil {
+		now := l.now()
+		rate := l.profile.Rate(now.Sub(l.start))
+		if rate < 0 {
+			return 0
+		}
+
+		l.tokens += rate * now.Sub(l.last).Seconds()
+		l.tokens = math.Min(l.tokens, math.Max(1, rate))
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.lag = time.Duration((l.tokens - 1) / math.Max(1, rate) * float64(time.Second))
+			n := math.Floor(l.tokens)
+			l.tokens -= n
+			return int(n)
+		}
+
+		// Sleep until the next token, waking at least every
+		// 100ms to follow the profile and at most every 1ms.
+		wait := 100 * time.Millisecond
+		if rate > 0 {
+			wait = time.Duration((1 - l.tokens) / rate * float64(time.Second))
+		}
+
+		switch {
+		case wait < time.Millisecond:
+			wait = time.Millisecond
+		case wait > 100*time.Millisecond:
+			wait = 100 * time.Millisecond
+		}
+
+		l.sleep(ctx, wait)
+	}
+
+	return 0
+}
+
+// Lag returns how far behind schedule the last Take was.
+func (l *Limiter) Lag() time.Duration {
+	return l.lag
+}
+
+// Sleep for `d` or until `ctx` is done.
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
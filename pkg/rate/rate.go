@@ -0,0 +1,239 @@
+package rate
+
+import "strconv"
+import "strings"
+import "math"
+import "time"
+import "fmt"
+
+// Profile interface, returns the target rate in
+// records per second at `t` since the profile started.
+type Profile interface {
+	Rate(t time.Duration) float64
+}
+
+// Constant profile.
+type Constant float64
+
+// Rate implementation.
+func (c Constant) Rate(time.Duration) float64 {
+	return float64(c)
+}
+
+// Ramp profile, goes linearly from `From` to `To` over `Over`.
+type Ramp struct {
+	From, To float64
+	Over     time.Duration
+}
+
+// Rate implementation.
+func (r Ramp) Rate(t time.Duration) float64 {
+	if t >= r.Over || r.Over <= 0 {
+		return r.To
+	}
+	return r.From + (r.To-r.From)*float64(t)/float64(r.Over)
+}
+
+// Step profile, starts at `From` and adds `By` every `Every` up to `To`.
+type Step struct {
+	From, To, By float64
+	Every        time.Duration
+}
+
+// Rate implementation.
+func (s Step) Rate(t time.Duration) float64 {
+	rate := s.From + s.By*float64(t/s.Every)
+	if (s.By > 0 && rate > s.To) || (s.By < 0 && rate < s.To) {
+		return s.To
+	}
+	return rate
+}
+
+// Sine profile, oscillates around `Base` by `Amplitude` every `Period`.
+type Sine struct {
+	Base, Amplitude float64
+	Period          time.Duration
+}
+
+// Rate implementation.
+func (s Sine) Rate(t time.Duration) float64 {
+	rate := s.Base + s.Amplitude*math.Sin(2*math.Pi*float64(t)/float64(s.Period))
+	return math.Max(0, rate)
+}
+
+// Burst profile, runs at `Base` with a burst at `Peak` lasting
+// `Length` at the start of every `Every`.
+type Burst struct {
+	Base, Peak    float64
+	Every, Length time.Duration
+}
+
+// Rate implementation.
+func (b Burst) Rate(t time.Duration) float64 {
+	if t%b.Every < b.Length {
+		return b.Peak
+	}
+	return b.Base
+}
+
+// Stage structure.
+type stage struct {
+	profile Profile
+	length  time.Duration
+}
+
+// Sequence profile, runs each stage for its length,
+// a sequence whose last stage has a length ends after it.
+type Sequence []stage
+
+// Rate implementation, returns -1 once the sequence has ended.
+func (s Sequence) Rate(t time.Duration) float64 {
+	for i, st := range s {
+		if st.length == 0 || t < st.length {
+			return st.profile.Rate(t)
+		}
+		if i < len(s)-1 {
+			t -= st.length
+		}
+	}
+	return -1
+}
+
+// Parse a profile, stages are separated by ";" or newlines
+// and run in order, each stage is one of:
+//
+//	5000/s                       constant rate, per s, m or h
+//	ramp:100/s,5000/s            linear ramp over the stage
+//	step:100/s,1000/s,100/s,1m   add 100/s every minute up to 1000/s
+//	sine:1000/s,500/s,24h        1000/s +/- 500/s over 24h
+//	burst:100/s,5000/s,1m,5s     5000/s for 5s every minute, 100/s otherwise
+//
+// followed by an optional "for <duration>", lines starting with # are ignored.
+func Parse(spec string) (Profile, error) {
+	var seq Sequence
+
+	lines := strings.FieldsFunc(spec, func(r rune) bool { return r == ';' || r == '\n' })
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if len(seq) > 0 && seq[len(seq)-1].length == 0 {
+			return nil, fmt.Errorf("rate: only the last stage may run forever")
+		}
+
+		st, err := parseStage(line)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, st)
+	}
+
+	switch len(seq) {
+	case 0:
+		return nil, fmt.Errorf("rate: empty profile")
+	case 1:
+		if seq[0].length == 0 {
+			return seq[0].profile, nil
+		}
+	}
+
+	return seq, nil
+}
+
+// Parse a single stage.
+func parseStage(s string) (stage, error) {
+	var st stage
+	var err error
+
+	if i := strings.Index(s, " for "); i != -1 {
+		if st.length, err = duration(strings.TrimSpace(s[i+5:])); err != nil {
+			return st, err
+		}
+		s = strings.TrimSpace(s[:i])
+	}
+
+	kind, args := "", []string{s}
+	if i := strings.Index(s, ":"); i != -1 {
+		kind, args = s[:i], strings.Split(s[i+1:], ",")
+	}
+
+	arity := map[string]int{"": 1, "ramp": 2, "step": 4, "sine": 3, "burst": 4}
+	n, ok := arity[kind]
+	if !ok {
+		return st, fmt.Errorf("rate: unknown profile %q", kind)
+	}
+	if len(args) != n {
+		return st, fmt.Errorf("rate: %s expects %d arguments, got %q", kind, n, s)
+	}
+
+	// Parse each argument as a rate or a duration in turn.
+	var rates []float64
+	var durations []time.Duration
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if strings.Contains(arg, "/") {
+			r, err := parseRate(arg)
+			if err != nil {
+				return st, err
+			}
+			rates = append(rates, r)
+		} else {
+			d, err := duration(arg)
+			if err != nil {
+				return st, err
+			}
+			durations = append(durations, d)
+		}
+	}
+
+	switch {
+	case kind == "" && len(rates) == 1:
+		st.profile = Constant(rates[0])
+	case kind == "ramp" && len(rates) == 2:
+		if st.length == 0 {
+			return st, fmt.Errorf("rate: ramp requires a length, e.g %q", s+" for 10m")
+		}
+		st.profile = Ramp{From: rates[0], To: rates[1], Over: st.length}
+	case kind == "step" && len(rates) == 3 && len(durations) == 1:
+		st.profile = Step{From: rates[0], To: rates[1], By: rates[2], Every: durations[0]}
+	case kind == "sine" && len(rates) == 2 && len(durations) == 1:
+		st.profile = Sine{Base: rates[0], Amplitude: rates[1], Period: durations[0]}
+	case kind == "burst" && len(rates) == 2 && len(durations) == 2:
+		st.profile = Burst{Base: rates[0], Peak: rates[1], Every: durations[0], Length: durations[1]}
+	default:
+		return st, fmt.Errorf("rate: invalid %s arguments %q", kind, s)
+	}
+
+	return st, nil
+}
+
+// Parse a rate such as "5000/s" into records per second.
+func parseRate(s string) (float64, error) {
+	parts := strings.SplitN(s, "/", 2)
+	n, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("rate: invalid rate %q", s)
+	}
+
+	switch parts[1] {
+	case "s":
+		return n, nil
+	case "m":
+		return n / 60, nil
+	case "h":
+		return n / 3600, nil
+	default:
+		return 0, fmt.Errorf("rate: invalid rate %q, expected a unit of s, m or h", s)
+	}
+}
+
+// Parse a positive duration.
+func duration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("rate: invalid duration %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,122 @@
+package rate
+
+import "github.com/bmizerany/assert"
+import "math/rand"
+import "testing"
+import "context"
+import "time"
+import "math"
+
+func TestParse(t *testing.T) {
+	p, err := Parse("5000/s")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Constant(5000), p)
+
+	p, err = Parse("120/m")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2.0, p.Rate(0))
+
+	p, err = Parse("ramp:0/s,1000/s for 10s; step:1000/s,2000/s,500/s,1m for 5m; # comment\nburst:10/s,100/s,1m,5s")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 500.0, p.Rate(5*time.Second))
+	assert.Equal(t, 1000.0, p.Rate(10*time.Second))
+	assert.Equal(t, 1500.0, p.Rate(70*time.Second+time.Millisecond))
+	assert.Equal(t, 2000.0, p.Rate(4*time.Minute))
+	assert.Equal(t, 100.0, p.Rate(5*time.Minute+10*time.Second+time.Second))
+	assert.Equal(t, 10.0, p.Rate(5*time.Minute+10*time.Second+10*time.Second))
+
+	p, err = Parse("1/s for 1s")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, -1.0, p.Rate(time.Second))
+
+	p, err = Parse("sine:100/s,50/s,4h")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 150.0, p.Rate(time.Hour))
+
+	for _, spec := range []string{"", "5000", "5000/d", "ramp:1/s,2/s", "sine:1/s,2/s", "1/s; 2/s", "wave:1/s"} {
+		_, err := Parse(spec)
+		assert.NotEqual(t, nil, err, spec)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	l := NewLimiter(Constant(100000))
+	l.now = func() time.Time { return now }
+	l.sleep = func(_ context.Context, d time.Duration) { now = now.Add(d) }
+
+	n, takes := 0, 0
+	for now.Before(time.Unix(1, 0)) {
+		n += l.Take(ctx)
+		takes++
+	}
+
+	assert.T(t, n >= 99900 && n <= 100100, n)
+	assert.T(t, takes <= 1001, takes)
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, 100000, l.Take(ctx))
+}
+
+func TestLimiterEnd(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	p, _ := Parse("10/s for 1s")
+	l := NewLimiter(p)
+	l.now = func() time.Time { return now }
+	l.sleep = func(_ context.Context, d time.Duration) { now = now.Add(d) }
+
+	n := 0
+	for k := l.Take(ctx); k > 0; k = l.Take(ctx) {
+		n += k
+	}
+
+	assert.Equal(t, 10, n)
+}
+
+func TestArrivals(t *testing.T) {
+	ctx := context.Background()
+	run := func(seed int64) []time.Duration {
+		now := time.Unix(0, 0)
+		p, _ := Parse("100/s for 100s")
+		a, err := NewArrivals(p, "poisson", rand.New(rand.NewSource(seed)))
+		assert.Equal(t, nil, err)
+		a.now = func() time.Time { return now }
+		a.sleep = func(_ context.Context, d time.Duration) { now = now.Add(d) }
+
+		var ret []time.Duration
+		for a.Take(ctx) > 0 {
+			ret = append(ret, now.Sub(time.Unix(0, 0)))
+		}
+		return ret
+	}
+
+	a, b := run(1), run(1)
+	assert.Equal(t, a, b)
+	assert.T(t, len(a) > 9700 && len(a) < 10300, len(a))
+
+	// Exponential gaps have a standard deviation equal to their mean.
+	var sum, squares float64
+	for i := 1; i < len(a); i++ {
+		gap := (a[i] - a[i-1]).Seconds()
+		sum += gap
+		squares += gap * gap
+	}
+	n := float64(len(a) - 1)
+	mean := sum / n
+	std := math.Sqrt(squares/n - mean*mean)
+	assert.T(t, math.Abs(std-mean) < 0.1*mean, std, mean)
+
+	_, err := NewArrivals(Constant(1), "normal", nil)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestLimiterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := NewLimiter(Constant(1))
+	assert.Equal(t, 1, l.Take(ctx))
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	assert.Equal(t, 0, l.Take(ctx))
+}
@@ -0,0 +1,41 @@
+package rate
+
+import "context"
+import "time"
+
+// Pacer interface.
+//
+// Take blocks until records are due and returns their number,
+// or 0 when done, Lag returns how late the last Take was.
+type Pacer interface {
+	Take(ctx context.Context) int
+	Lag() time.Duration
+}
+
+// Ticker structure, paces one record every interval.
+type Ticker struct {
+	ticker *time.Ticker
+	lag    time.Duration
+}
+
+// NewTicker returns a ticker pacing a record every `d`.
+func NewTicker(d time.Duration) *Ticker {
+	return &Ticker{ticker: time.NewTicker(d)}
+}
+
+// Take implementation.
+func (t *Ticker) Take(ctx context.Context) int {
+	select {
+	case tick := <-t.ticker.C:
+		t.lag = time.Since(tick)
+		return 1
+	case <-ctx.Done():
+		t.ticker.Stop()
+		return 0
+	}
+}
+
+// Lag implementation.
+func (t *Ticker) Lag() time.Duration {
+	return t.lag
+}
@@ -0,0 +1,221 @@
+package schema
+
+import "github.com/yields/phony/pkg/phony"
+import "strings"
+import "fmt"
+
+// Entity structure.
+//
+// A root entity generates `count` rows, an entity with a `parent`
+// generates between `fanout[0]` and `fanout[1]` rows per parent row.
+//
+// Fields that `ref` the parent entity take the value of the parent
+// row, fields that ref any other entity take the value of a random
+// row of that entity.
+type Entity struct {
+	Name   string   `json:"name"`
+	Count  int      `json:"count,omitempty"`
+	Parent string   `json:"parent,omitempty"`
+	Fanout []int    `json:"fanout,omitempty"`
+	Fields []*Field `json:"fields"`
+	parent *Entity
+	deps   []*Entity
+	keep   bool
+	rows   [][]interface{}
+}
+
+// Reference structure.
+type ref struct {
+	entity *Entity
+	index  int
+}
+
+// Names returns all field names.
+func (e *Entity) Names() []string {
+	return names(e.Fields)
+}
+
+// Order returns all entities in dependency order.
+func (s *Schema) Order() []*Entity {
+	ret := make([]*Entity, 0, len(s.Entities))
+	seen := make(map[*Entity]bool)
+
+	var visit func(e *Entity)
+	visit = func(e *Entity) {
+		if seen[e] {
+			return
+		}
+		seen[e] = true
+		for _, d := range e.deps {
+			visit(d)
+		}
+		ret = append(ret, e)
+	}
+
+	for _, e := range s.Entities {
+		visit(e)
+	}
+
+	return ret
+}
+
+// Generate all rows, calling `fn` with each row.
+//
+// Entities must be generated in the order returned
+// by Schema.Order so that references can be resolved.
+func (e *Entity) Generate(fn func(row []interface{}) error) error {
+	e.rows = nil
+
+	if e.parent == nil {
+		for i := 0; i < e.Count; i++ {
+			if err := e.generate(nil, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	min, max := e.Fanout[0], e.Fanout[1]
+	for _, p := range e.parent.rows {
+		n := min + phony.Rand().Intn(max-min+1)
+		for i := 0; i < n; i++ {
+			if err := e.generate(p, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Generate a single row of `parent`.
+func (e *Entity) generate(parent []interface{}, fn func([]interface{}) error) error {
+	row := make([]interface{}, len(e.Fields))
+	g := phony.Record()
+
+	for i, f := range e.Fields {
+		if f.ref == nil {
+			v, err := f.value(g)
+			if err != nil {
+				return fmt.Errorf("schema: %s.%s: %s", e.Name, f.Name, err)
+			}
+			row[i] = v
+			continue
+		}
+
+		if f.Null > 0 && phony.Rand().Float64() < f.Null {
+			continue
+		}
+
+		if f.ref.entity == e.parent {
+			row[i] = parent[f.ref.index]
+			continue
+		}
+
+		rows := f.ref.entity.rows
+		if len(rows) == 0 {
+			return fmt.Errorf("schema: %s.%s: %s has no rows", e.Name, f.Name, f.ref.entity.Name)
+		}
+		row[i] = rows[phony.Rand().Intn(len(rows))][f.ref.index]
+	}
+
+	if e.keep {
+		e.rows = append(e.rows, row)
+	}
+
+	return fn(row)
+}
+
+// Link entities, resolving parents and references.
+func (s *Schema) link() error {
+	byName := make(map[string]*Entity)
+
+	for _, e := range s.Entities {
+		if e.Name == "" {
+			return fmt.Errorf("schema: entity name is required")
+		}
+		if byName[e.Name] != nil {
+			return fmt.Errorf("schema: duplicate entity %q", e.Name)
+		}
+		byName[e.Name] = e
+
+		if err := compile(e.Fields, true); err != nil {
+			return fmt.Errorf("%s (entity %s)", err, e.Name)
+		}
+	}
+
+	for _, e := range s.Entities {
+		if e.Parent != "" {
+			if e.parent = byName[e.Parent]; e.parent == nil || e.parent == e {
+				return fmt.Errorf("schema: %s: unknown parent %q", e.Name, e.Parent)
+			}
+			if len(e.Fanout) != 2 || e.Fanout[0] < 0 || e.Fanout[0] > e.Fanout[1] {
+				return fmt.Errorf("schema: %s: fanout must be [min, max]", e.Name)
+			}
+			e.parent.keep = true
+			e.deps = append(e.deps, e.parent)
+		} else if e.Count <= 0 {
+			return fmt.Errorf("schema: %s: count must be positive", e.Name)
+		}
+
+		for _, f := range e.Fields {
+			if f.Ref == "" {
+				continue
+			}
+
+			parts := strings.SplitN(f.Ref, ".", 2)
+			target := byName[parts[0]]
+			if len(parts) != 2 || target == nil || target == e {
+				return fmt.Errorf("schema: %s.%s: invalid ref %q", e.Name, f.Name, f.Ref)
+			}
+
+			f.ref = &ref{entity: target, index: -1}
+			for i, tf := range target.Fields {
+				if tf.Name == parts[1] {
+					f.ref.index = i
+				}
+			}
+
+			if f.ref.index == -1 {
+				return fmt.Errorf("schema: %s.%s: unknown field %q", e.Name, f.Name, f.Ref)
+			}
+
+			target.keep = true
+			e.deps = append(e.deps, target)
+		}
+	}
+
+	return s.cycles()
+}
+
+// Cycles returns an error if entities depend on each other.
+func (s *Schema) cycles() error {
+	state := make(map[*Entity]int)
+
+	var visit func(e *Entity) error
+	visit = func(e *Entity) error {
+		switch state[e] {
+		case 1:
+			return fmt.Errorf("schema: %s: circular reference", e.Name)
+		case 2:
+			return nil
+		}
+
+		state[e] = 1
+		for _, d := range e.deps {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		state[e] = 2
+		return nil
+	}
+
+	for _, e := range s.Entities {
+		if err := visit(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
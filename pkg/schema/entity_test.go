@@ -0,0 +1,81 @@
+package schema
+
+import "github.com/bmizerany/assert"
+import "testing"
+
+func TestEntities(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"entities": [
+			{ "name": "items", "parent": "orders", "fanout": [2, 2], "fields": [
+				{ "name": "order_id", "ref": "orders.id" },
+				{ "name": "user_id", "ref": "users.id" }
+			]},
+			{ "name": "users", "count": 3, "fields": [
+				{ "name": "id", "path": "uuid" }
+			]},
+			{ "name": "orders", "parent": "users", "fanout": [1, 3], "fields": [
+				{ "name": "id", "path": "uuid" },
+				{ "name": "user_id", "ref": "users.id" }
+			]}
+		]
+	}`))
+	assert.Equal(t, nil, err)
+
+	order := s.Order()
+	assert.Equal(t, "users", order[0].Name)
+	assert.Equal(t, "orders", order[1].Name)
+	assert.Equal(t, "items", order[2].Name)
+
+	rows := make(map[string][][]interface{})
+	for _, e := range order {
+		err := e.Generate(func(row []interface{}) error {
+			rows[e.Name] = append(rows[e.Name], row)
+			return nil
+		})
+		assert.Equal(t, nil, err)
+	}
+
+	users := make(map[interface{}]bool)
+	for _, u := range rows["users"] {
+		users[u[0]] = true
+	}
+	assert.Equal(t, 3, len(users))
+
+	orders := make(map[interface{}]int)
+	for _, o := range rows["orders"] {
+		assert.T(t, users[o[1]])
+		orders[o[0]] = 0
+	}
+	assert.T(t, len(orders) >= 3 && len(orders) <= 9)
+
+	for _, it := range rows["items"] {
+		_, ok := orders[it[0]]
+		assert.T(t, ok)
+		assert.T(t, users[it[1]])
+		orders[it[0]]++
+	}
+
+	for _, n := range orders {
+		assert.Equal(t, 2, n)
+	}
+}
+
+func TestEntityErrors(t *testing.T) {
+	schemas := []string{
+		`{ "entities": [{ "name": "a", "count": 1, "fields": [{ "name": "x", "ref": "b.id" }] }] }`,
+		`{ "entities": [{ "name": "a", "parent": "a", "fanout": [1, 2], "fields": [{ "name": "x", "path": "id" }] }] }`,
+		`{ "entities": [{ "name": "a", "parent": "b", "fanout": [2, 1], "fields": [{ "name": "x", "path": "id" }] },
+		                { "name": "b", "count": 1, "fields": [{ "name": "x", "path": "id" }] }] }`,
+		`{ "entities": [{ "name": "a", "count": 1, "fields": [{ "name": "x", "ref": "b.x" }] },
+		                { "name": "b", "count": 1, "fields": [{ "name": "x", "ref": "a.x" }] }] }`,
+		`{ "fields": [{ "name": "x", "ref": "a.x" }] }`,
+		`{ "entities": [{ "name": "a", "fields": [{ "name": "x", "path": "id" }] }] }`,
+		`{ "entities": [{ "name": "a", "count": 0, "fields": [{ "name": "x", "path": "id" }] }] }`,
+		`{ "entities": [{ "name": "a", "count": -1, "fields": [{ "name": "x", "path": "id" }] }] }`,
+	}
+
+	for _, s := range schemas {
+		_, err := Parse([]byte(s))
+		assert.NotEqual(t, nil, err)
+	}
+}
@@ -0,0 +1,382 @@
+package schema
+
+import "github.com/yields/phony/pkg/phony"
+import "encoding/json"
+import "encoding/csv"
+import "strconv"
+import "strings"
+import "regexp"
+import "bytes"
+import "time"
+import "fmt"
+
+// Value shapes, checked in order.
+var shapes = []struct {
+	path string
+	expr *regexp.Regexp
+}{
+	{"uuid", regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)},
+	{"ksuid", regexp.MustCompile(`^[0-9a-zA-Z]{27}$`)},
+	{"email", regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[a-zA-Z]+$`)},
+	{"ipv4", regexp.MustCompile(`^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}$`)},
+	{"mac.address", regexp.MustCompile(`^[0-9a-fA-F]{1,2}(:[0-9a-fA-F]{1,2}){5}$`)},
+	{"ipv6", regexp.MustCompile(`^[0-9a-fA-F]{0,4}(:[0-9a-fA-F]{0,4}){2,7}$`)},
+}
+
+// Column name aliases.
+var aliases = map[string]string{
+	"firstname": "name.first",
+	"lastname":  "name.last",
+	"surname":   "name.last",
+	"fullname":  "name",
+	"user":      "username",
+	"login":     "username",
+	"handle":    "username",
+	"mail":      "email",
+	"ip":        "ipv4",
+	"ipaddress": "ipv4",
+	"mac":       "mac.address",
+	"lat":       "latitude",
+	"lng":       "longitude",
+	"lon":       "longitude",
+	"tz":        "timezone",
+	"method":    "http.method",
+	"action":    "event.action",
+	"event":     "event.action",
+	"category":  "product.category",
+	"product":   "product.name",
+	"company":   "company.name",
+	"tld":       "domain.tld",
+	"website":   "domain",
+	"image":     "avatar",
+	"photo":     "avatar",
+	"picture":   "avatar",
+}
+
+// Infer a schema from a JSON or CSV sample.
+//
+// JSON samples are an array of objects or newline delimited
+// objects, nested objects are flattened to dotted names.
+// CSV samples must start with a header row.
+func Infer(sample []byte) (*Schema, error) {
+	var cols []string
+	var values map[string][]string
+	var err error
+
+	switch b := bytes.TrimSpace(sample); {
+	case len(b) == 0:
+		return nil, fmt.Errorf("schema: empty sample")
+	case b[0] == '[' || b[0] == '{':
+		cols, values, err = readJSON(b)
+	default:
+		cols, values, err = readCSV(b)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("schema: %s", err)
+	}
+
+	var s Schema
+	for _, c := range cols {
+		s.Fields = append(s.Fields, infer(c, values[c]))
+	}
+
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Infer a field from column `name` and its sampled `values`,
+// empty values are counted as NULL.
+func infer(name string, values []string) *Field {
+	f := &Field{Name: name}
+	set := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if v != "" {
+			set = append(set, v)
+		}
+	}
+
+	if len(values) > 0 && len(set) < len(values) {
+		null := float64(len(values)-len(set)) / float64(len(values))
+		f.Null, _ = strconv.ParseFloat(strconv.FormatFloat(null, 'f', 2, 64), 64)
+	}
+
+	for _, s := range shapes {
+		if len(set) > 0 && all(set, s.expr.MatchString) {
+			f.Path = s.path
+			return f
+		}
+	}
+
+	if from, to, ok := times(set, time.RFC3339); ok {
+		f.Template = fmt.Sprintf("{{ datetime:%s,%s }}", from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+		return f
+	}
+
+	if from, to, ok := times(set, "2006-01-02"); ok {
+		f.Template = fmt.Sprintf("{{ date:%s,%s }}", from.Format("2006-01-02"), to.Format("2006-01-02"))
+		return f
+	}
+
+	if len(set) > 0 && all(set, isBool) {
+		f.Path, f.Type = "bool", "bool"
+		return f
+	}
+
+	switch path := Lookup(name); path {
+	case "":
+	case "latitude", "longitude", "double":
+		f.Path, f.Type = path, "float"
+		return f
+	case "unixtime":
+		f.Path, f.Type = path, "int"
+		return f
+	default:
+		if _, _, _, numeric := floatRange(set); !numeric {
+			f.Path = path
+			return f
+		}
+	}
+
+	if min, max, ok := intRange(set); ok {
+		f.Template = fmt.Sprintf("{{ int:%d,%d }}", min, max)
+		f.Type = "int"
+		return f
+	}
+
+	if min, max, prec, ok := floatRange(set); ok {
+		f.Template = fmt.Sprintf("{{ float:%s,%s,%d }}", fmtFloat(min), fmtFloat(max), prec)
+		f.Type = "float"
+		return f
+	}
+
+	f.Path = "id"
+	return f
+}
+
+// Lookup returns the generator path matching a column
+// or property `name`, e.g "first_name" is "name.first".
+func Lookup(name string) string {
+	key := normalize(name)
+
+	if path, ok := aliases[key]; ok {
+		return path
+	}
+
+	for _, path := range phony.List() {
+		parts := strings.Split(path, ".")
+		if key == normalize(path) {
+			return path
+		}
+		if len(parts) == 2 && key == normalize(parts[1]+parts[0]) {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// Normalize a column name, e.g "First_Name" becomes "firstname".
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	return strings.NewReplacer("_", "", "-", "", " ", "", ".", "").Replace(s)
+}
+
+// Return the range of `values` parsed with `layout`.
+func times(values []string, layout string) (from, to time.Time, ok bool) {
+	if len(values) == 0 {
+		return from, to, false
+	}
+
+	for i, v := range values {
+		t, err := time.Parse(layout, v)
+		if err != nil {
+			return from, to, false
+		}
+		if i == 0 || t.Before(from) {
+			from = t
+		}
+		if i == 0 || t.After(to) {
+			to = t
+		}
+	}
+
+	return from, to, true
+}
+
+// Return the range of integer `values`.
+func intRange(values []string) (min, max int64, ok bool) {
+	if len(values) == 0 {
+		return 0, 0, false
+	}
+
+	for i, v := range values {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		if i == 0 || n < min {
+			min = n
+		}
+		if i == 0 || n > max {
+			max = n
+		}
+	}
+
+	return min, max, true
+}
+
+// Return the range and precision of float `values`.
+func floatRange(values []string) (min, max float64, prec int, ok bool) {
+	if len(values) == 0 {
+		return 0, 0, 0, false
+	}
+
+	for i, v := range values {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		if i == 0 || n < min {
+			min = n
+		}
+		if i == 0 || n > max {
+			max = n
+		}
+		if j := strings.Index(v, "."); j != -1 && len(v)-j-1 > prec {
+			prec = len(v) - j - 1
+		}
+	}
+
+	return min, max, prec, true
+}
+
+// Format a float for use in a template argument.
+func fmtFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Report whether `s` is a boolean.
+func isBool(s string) bool {
+	return s == "true" || s == "false"
+}
+
+// Report whether `fn` matches all `values`.
+func all(values []string, fn func(string) bool) bool {
+	for _, v := range values {
+		if !fn(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Read a CSV sample.
+func readCSV(b []byte) ([]string, map[string][]string, error) {
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cols := records[0]
+	values := make(map[string][]string)
+
+	for _, rec := range records[1:] {
+		for i, c := range cols {
+			values[c] = append(values[c], rec[i])
+		}
+	}
+
+	return cols, values, nil
+}
+
+// Read a JSON sample, keeping the order of keys.
+func readJSON(b []byte) ([]string, map[string][]string, error) {
+	var cols []string
+	var rows []map[string]string
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	if b[0] == '[' {
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for dec.More() {
+		row := make(map[string]string)
+		if err := object(dec, "", row, &cols); err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	values := make(map[string][]string)
+	for _, row := range rows {
+		for _, c := range cols {
+			values[c] = append(values[c], row[c])
+		}
+	}
+
+	return cols, values, nil
+}
+
+// Read an object from `dec` into `row`, appending new keys to `cols`.
+func object(dec *json.Decoder, prefix string, row map[string]string, cols *[]string) error {
+	if t, err := dec.Token(); err != nil {
+		return err
+	} else if t != json.Delim('{') {
+		return fmt.Errorf("expected an object, got %v", t)
+	}
+
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := prefix + t.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		if raw[0] != '{' && !contains(*cols, key) {
+			*cols = append(*cols, key)
+		}
+
+		switch raw[0] {
+		case '{':
+			sub := json.NewDecoder(bytes.NewReader(raw))
+			sub.UseNumber()
+			if err := object(sub, key+".", row, cols); err != nil {
+				return err
+			}
+		case '"':
+			var s string
+			json.Unmarshal(raw, &s)
+			row[key] = s
+		case 'n':
+			row[key] = ""
+		default:
+			row[key] = string(raw)
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+// Report whether `list` contains `s`.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
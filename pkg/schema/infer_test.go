@@ -0,0 +1,39 @@
+package schema
+
+import "github.com/bmizerany/assert"
+import "testing"
+
+func TestInferCSV(t *testing.T) {
+	s, err := Infer([]byte("" +
+		"id,First_Name,email,signup,created_at,score,active,country,notes\n" +
+		"1,Amir,a@b.com,2020-01-02,2020-01-01T10:00:00Z,1.25,true,France,x\n" +
+		"7,Jane,c@d.org,2021-03-04,2022-05-01T00:00:00Z,3.5,false,Spain,\n"))
+	assert.Equal(t, nil, err)
+
+	f := s.Fields
+	assert.Equal(t, "{{ int:1,7 }}", f[0].Template)
+	assert.Equal(t, "int", f[0].Type)
+	assert.Equal(t, "name.first", f[1].Path)
+	assert.Equal(t, "email", f[2].Path)
+	assert.Equal(t, "{{ date:2020-01-02,2021-03-04 }}", f[3].Template)
+	assert.Equal(t, "{{ datetime:2020-01-01T10:00:00Z,2022-05-01T00:00:00Z }}", f[4].Template)
+	assert.Equal(t, "{{ float:1.25,3.5,2 }}", f[5].Template)
+	assert.Equal(t, "bool", f[6].Type)
+	assert.Equal(t, "country", f[7].Path)
+	assert.Equal(t, 0.5, f[8].Null)
+
+	_, err = s.Generate()
+	assert.Equal(t, nil, err)
+}
+
+func TestInferJSON(t *testing.T) {
+	s, err := Infer([]byte(`[
+		{ "user_id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "address": { "ip": "10.0.0.1", "lat": 1.5 } },
+		{ "user_id": "6ba7b811-9dad-11d1-80b4-00c04fd430c8", "address": { "ip": "10.0.0.2", "lat": null } }
+	]`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"user_id", "address.ip", "address.lat"}, s.Names())
+	assert.Equal(t, "uuid", s.Fields[0].Path)
+	assert.Equal(t, "ipv4", s.Fields[1].Path)
+	assert.Equal(t, 0.5, s.Fields[2].Null)
+}
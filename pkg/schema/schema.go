@@ -0,0 +1,231 @@
+package schema
+
+import "github.com/yields/phony/pkg/phony"
+import "encoding/json"
+import "strconv"
+import "strings"
+import "fmt"
+
+// Field structure.
+//
+// A field is either a generator `path` such as "email"
+// or a `template` such as "{{ name.first }}-{{ id }}".
+//
+// The generated text is converted to `type`, one of "string",
+// "int", "float" or "bool", and `null` is the probability of
+// the field being NULL instead.
+//
+// Within entities a field may instead `ref` a field of another
+// entity, e.g "users.id", see Entity.
+type Field struct {
+	Name     string  `json:"name"`
+	Path     string  `json:"path,omitempty"`
+	Template string  `json:"template,omitempty"`
+	Ref      string  `json:"ref,omitempty"`
+	Type     string  `json:"type,omitempty"`
+	Null     float64 `json:"null,omitempty"`
+	tmpl     *phony.Template
+	ref      *ref
+}
+
+// Schema structure.
+//
+// A schema either has `fields` describing a single
+// record or related `entities`.
+type Schema struct {
+	Fields   []*Field  `json:"fields,omitempty"`
+	Entities []*Entity `json:"entities,omitempty"`
+}
+
+// Parse a JSON schema.
+func Parse(b []byte) (*Schema, error) {
+	var s Schema
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("schema: %s", err)
+	}
+
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Columns returns a schema from a comma separated
+// list of columns, each column is either a path or
+// a `name=path` pair, e.g "id=uuid,email,company=company.name".
+func Columns(list string) (*Schema, error) {
+	var s Schema
+
+	for _, col := range strings.Split(list, ",") {
+		col = strings.TrimSpace(col)
+		if col == "" {
+			continue
+		}
+
+		name, path := col, col
+		if i := strings.Index(col, "="); i != -1 {
+			name, path = col[:i], col[i+1:]
+		}
+
+		s.Fields = append(s.Fields, &Field{Name: name, Path: path})
+	}
+
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Names returns all field names.
+func (s *Schema) Names() []string {
+	return names(s.Fields)
+}
+
+// Generate a single row, values are nil, string,
+// int64, float64 or bool depending on the field type.
+func (s *Schema) Generate() ([]interface{}, error) {
+	return s.GenerateWith(nil)
+}
+
+// GenerateWith generates a single row with generator `g`,
+// a nil `g` uses the default generator. Entities such as
+// `person` are the same across the fields of a row.
+func (s *Schema) GenerateWith(g *phony.Generator) ([]interface{}, error) {
+	ret := make([]interface{}, len(s.Fields))
+
+	if g == nil {
+		g = phony.Record()
+	} else {
+		g = g.Record()
+	}
+
+	for i, f := range s.Fields {
+		v, err := f.value(g)
+		if err != nil {
+			return nil, fmt.Errorf("schema: %s: %s", f.Name, err)
+		}
+		ret[i] = v
+	}
+
+	return ret, nil
+}
+
+// Kind returns the field type, "string", "int", "float" or "bool",
+// references have the type of the referenced field.
+func (f *Field) Kind() string {
+	switch {
+	case f.ref != nil:
+		return f.ref.entity.Fields[f.ref.index].Kind()
+	case f.Type == "":
+		return "string"
+	default:
+		return f.Type
+	}
+}
+
+// Nullable reports whether the field may be NULL.
+func (f *Field) Nullable() bool {
+	if f.Null > 0 {
+		return true
+	}
+	if f.ref != nil {
+		return f.ref.entity.Fields[f.ref.index].Nullable()
+	}
+	return false
+}
+
+// Generate the field value with `g`.
+func (f *Field) value(g *phony.Generator) (interface{}, error) {
+	r := phony.Rand()
+	if g != nil {
+		r = g.Rand()
+	}
+
+	if f.Null > 0 && r.Float64() < f.Null {
+		return nil, nil
+	}
+
+	s, err := f.tmpl.ExecuteWith(g)
+	if err != nil {
+		return nil, err
+	}
+
+	switch f.Type {
+	case "int":
+		return strconv.ParseInt(s, 10, 64)
+	case "float":
+		return strconv.ParseFloat(s, 64)
+	case "bool":
+		return strconv.ParseBool(s)
+	default:
+		return s, nil
+	}
+}
+
+// Compile the schema.
+func (s *Schema) compile() error {
+	if len(s.Entities) == 0 {
+		return compile(s.Fields, false)
+	}
+
+	if len(s.Fields) != 0 {
+		return fmt.Errorf("schema: fields and entities are exclusive")
+	}
+
+	return s.link()
+}
+
+// Compile `fields`, `refs` reports whether references are allowed.
+func compile(fields []*Field, refs bool) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("schema: no fields")
+	}
+
+	seen := make(map[string]bool)
+
+	for _, f := range fields {
+		if f.Name == "" {
+			return fmt.Errorf("schema: field name is required")
+		}
+
+		if seen[f.Name] {
+			return fmt.Errorf("schema: duplicate field %q", f.Name)
+		}
+		seen[f.Name] = true
+
+		switch f.Type {
+		case "", "string", "int", "float", "bool":
+		default:
+			return fmt.Errorf("schema: %s: unknown type %q", f.Name, f.Type)
+		}
+
+		switch {
+		case f.Ref != "":
+			if !refs {
+				return fmt.Errorf("schema: %s: ref requires entities", f.Name)
+			}
+		case f.Template != "":
+			f.tmpl = phony.Compile(f.Template)
+		case f.Path != "":
+			f.tmpl = phony.Compile("{{ " + f.Path + " }}")
+		default:
+			return fmt.Errorf("schema: %s: path or template is required", f.Name)
+		}
+	}
+
+	return nil
+}
+
+// Names returns the names of `fields`.
+func names(fields []*Field) []string {
+	ret := make([]string, len(fields))
+
+	for i, f := range fields {
+		ret[i] = f.Name
+	}
+
+	return ret
+}
@@ -0,0 +1,57 @@
+package schema
+
+import "github.com/bmizerany/assert"
+import "testing"
+
+func TestColumns(t *testing.T) {
+	s, err := Columns("id=uuid, email,company=company.name")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"id", "email", "company"}, s.Names())
+
+	row, err := s.Generate()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(row))
+	assert.Equal(t, 36, len(row[0].(string)))
+}
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"fields": [
+			{ "name": "user", "template": "user-{{ id }}" },
+			{ "name": "country", "path": "country.code" }
+		]
+	}`))
+	assert.Equal(t, nil, err)
+
+	row, err := s.Generate()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 15, len(row[0].(string)))
+	assert.NotEqual(t, "", row[1])
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse([]byte(`{ "fields": [] }`))
+	assert.NotEqual(t, nil, err)
+
+	_, err = Parse([]byte(`{ "fields": [{ "name": "a" }] }`))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestTypes(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"fields": [
+			{ "name": "n", "template": "42", "type": "int" },
+			{ "name": "f", "template": "1.5", "type": "float" },
+			{ "name": "b", "template": "true", "type": "bool" },
+			{ "name": "x", "path": "uuid", "null": 1 }
+		]
+	}`))
+	assert.Equal(t, nil, err)
+
+	row, err := s.Generate()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []interface{}{int64(42), 1.5, true, nil}, row)
+
+	_, err = Parse([]byte(`{ "fields": [{ "name": "a", "path": "id", "type": "date" }] }`))
+	assert.NotEqual(t, nil, err)
+}
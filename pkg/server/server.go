@@ -0,0 +1,383 @@
+package server
+
+import "github.com/yields/phony/pkg/jsonschema"
+import "github.com/yields/phony/pkg/schema"
+import "github.com/yields/phony/pkg/phony"
+import "path/filepath"
+import "encoding/json"
+import "io/ioutil"
+import "net/http"
+import "strconv"
+import "strings"
+import "bytes"
+import "sync"
+import "time"
+import "fmt"
+import "os"
+
+// Maximum records per response.
+const maxCount = 10000
+
+// Route structure.
+//
+// A route renders a `template`, a `schema` given inline
+// or as a path to a schema file, or a `jsonschema` ref
+// such as "openapi.json#/components/schemas/User".
+type Route struct {
+	Path       string          `json:"path"`
+	Template   string          `json:"template,omitempty"`
+	Schema     json.RawMessage `json:"schema,omitempty"`
+	JSONSchema string          `json:"jsonschema,omitempty"`
+	render     func() ([]byte, error)
+}
+
+// Config structure.
+type Config struct {
+	Routes []*Route `json:"routes"`
+}
+
+// Server structure.
+type Server struct {
+	path   string
+	mod    time.Time
+	mu     sync.RWMutex
+	routes map[string]*Route
+	gen    sync.Mutex
+}
+
+// New returns a server for the routes in the config file at `path`.
+func New(path string) (*Server, error) {
+	s := &Server{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload the config file.
+func (s *Server) Reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return fmt.Errorf("server: %s: %s", s.path, err)
+	}
+
+	routes := make(map[string]*Route)
+	for _, r := range c.Routes {
+		if err := r.compile(filepath.Dir(s.path)); err != nil {
+			return err
+		}
+		routes[r.Path] = r
+	}
+
+	s.mu.Lock()
+	s.routes = routes
+	s.mod = info.ModTime()
+	s.mu.Unlock()
+	return nil
+}
+
+// Watch the config file every `interval`, reloading it when it
+// changes, reload errors are passed to `fn` and the previous
+// routes are kept.
+func (s *Server) Watch(interval time.Duration, fn func(error)) {
+	for range time.Tick(interval) {
+		info, err := os.Stat(s.path)
+		if err != nil {
+			fn(err)
+			continue
+		}
+
+		s.mu.RLock()
+		changed := !info.ModTime().Equal(s.mod)
+		s.mu.RUnlock()
+
+		if changed {
+			if err := s.Reload(); err != nil {
+				fn(err)
+			}
+		}
+	}
+}
+
+// ServeHTTP implementation.
+//
+// Responds with a JSON array of `count` records, `seed` makes
+// the response reproducible. With `stream=sse` or an Accept header
+// of text/event-stream records are sent as server-sent events every
+// `tick`, `stream=ndjson` sends newline delimited JSON instead.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		fail(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	route, ok := s.routes[r.URL.Path]
+	s.mu.RUnlock()
+
+	if !ok {
+		fail(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	q := r.URL.Query()
+	stream := q.Get("stream")
+	if stream == "" && strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		stream = "sse"
+	}
+
+	count := 10
+	if stream != "" {
+		count = -1
+	}
+
+	if v := q.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxCount {
+			fail(w, http.StatusBadRequest, fmt.Sprintf("count must be between 0 and %d", maxCount))
+			return
+		}
+		count = n
+	}
+
+	switch stream {
+	case "":
+		s.batch(w, route, count, q.Get("seed"))
+	case "sse", "ndjson":
+		tick := time.Second
+		if v := q.Get("tick"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				fail(w, http.StatusBadRequest, "tick must be a positive duration")
+				return
+			}
+			tick = d
+		}
+		s.stream(w, r, route, stream, count, tick)
+	default:
+		fail(w, http.StatusBadRequest, "stream must be sse or ndjson")
+	}
+}
+
+// Respond with a JSON array of `count` records.
+func (s *Server) batch(w http.ResponseWriter, route *Route, count int, seed string) {
+	var n int64
+	var err error
+
+	if seed != "" {
+		if n, err = strconv.ParseInt(seed, 10, 64); err != nil {
+			fail(w, http.StatusBadRequest, "seed must be an integer")
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+
+	s.gen.Lock()
+	if seed != "" {
+		phony.Seed(n)
+	}
+
+	for i := 0; i < count && err == nil; i++ {
+		var b []byte
+		if b, err = route.render(); err == nil {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(b)
+		}
+	}
+
+	if seed != "" {
+		phony.Seed(time.Now().UnixNano())
+	}
+	s.gen.Unlock()
+
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	buf.WriteByte(']')
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf.Bytes())
+}
+
+// Stream `count` records every `tick` until the client goes away.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, route *Route, mode string, count int, tick time.Duration) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		fail(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	if mode == "sse" {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for i := 0; count == -1 || i < count; i++ {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.gen.Lock()
+		b, err := route.render()
+		s.gen.Unlock()
+
+		if mode == "sse" {
+			if err != nil {
+				fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+				flusher.Flush()
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", b)
+		} else {
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "%s\n", b)
+		}
+
+		flusher.Flush()
+	}
+}
+
+// Compile the route, files are relative to `dir`.
+func (r *Route) compile(dir string) error {
+	if !strings.HasPrefix(r.Path, "/") {
+		return fmt.Errorf("server: route path %q must start with /", r.Path)
+	}
+
+	switch {
+	case r.Template != "":
+		t := phony.Compile(r.Template)
+		r.render = func() ([]byte, error) {
+			s, err := t.Execute()
+			if err != nil {
+				return nil, err
+			}
+			if json.Valid([]byte(s)) {
+				return []byte(s), nil
+			}
+			return json.Marshal(s)
+		}
+	case r.Schema != nil:
+		b := []byte(r.Schema)
+
+		var path string
+		if json.Unmarshal(b, &path) == nil {
+			var err error
+			if b, err = ioutil.ReadFile(resolve(dir, path)); err != nil {
+				return err
+			}
+		}
+
+		s, err := schema.Parse(b)
+		if err != nil {
+			return fmt.Errorf("server: %s: %s", r.Path, err)
+		}
+
+		if len(s.Entities) > 0 {
+			return fmt.Errorf("server: %s: entities are not supported", r.Path)
+		}
+
+		r.render = func() ([]byte, error) {
+			return object(s)
+		}
+	case r.JSONSchema != "":
+		path, pointer := r.JSONSchema, ""
+		if i := strings.Index(path, "#"); i != -1 {
+			path, pointer = path[:i], path[i+1:]
+		}
+
+		b, err := ioutil.ReadFile(resolve(dir, path))
+		if err != nil {
+			return err
+		}
+
+		g, err := jsonschema.Parse(b, pointer)
+		if err != nil {
+			return fmt.Errorf("server: %s: %s", r.Path, err)
+		}
+
+		r.render = func() ([]byte, error) {
+			v, err := g.Generate()
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(v)
+		}
+	default:
+		return fmt.Errorf("server: %s: template, schema or jsonschema is required", r.Path)
+	}
+
+	return nil
+}
+
+// Generate a JSON object from `s`, keeping the order of fields.
+func object(s *schema.Schema) ([]byte, error) {
+	row, err := s.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, f := range s.Fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, _ := json.Marshal(f.Name)
+		v, err := json.Marshal(row[i])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Resolve `path` relative to `dir`.
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// Respond with a JSON error.
+func fail(w http.ResponseWriter, code int, msg string) {
+	b, _ := json.Marshal(map[string]string{"error": msg})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
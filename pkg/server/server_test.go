@@ -0,0 +1,113 @@
+package server
+
+import "github.com/bmizerany/assert"
+import "net/http/httptest"
+import "path/filepath"
+import "encoding/json"
+import "io/ioutil"
+import "net/http"
+import "testing"
+import "strings"
+import "bufio"
+import "time"
+import "os"
+
+const config = `{
+  "routes": [
+    {"path": "/users", "schema": {"fields": [
+      {"name": "id", "path": "uuid"},
+      {"name": "age", "template": "{{ int:18,99 }}", "type": "int"}
+    ]}},
+    {"path": "/names", "template": "{{ name }}"}
+  ]
+}`
+
+func setup(t *testing.T) (*Server, string) {
+	path := filepath.Join(t.TempDir(), "routes.json")
+	assert.Equal(t, nil, ioutil.WriteFile(path, []byte(config), 0644))
+	s, err := New(path)
+	assert.Equal(t, nil, err)
+	return s, path
+}
+
+func get(t *testing.T, url string) (int, []interface{}) {
+	res, err := http.Get(url)
+	assert.Equal(t, nil, err)
+	defer res.Body.Close()
+
+	var v []interface{}
+	if res.StatusCode == 200 {
+		assert.Equal(t, nil, json.NewDecoder(res.Body).Decode(&v))
+	}
+
+	return res.StatusCode, v
+}
+
+func TestServe(t *testing.T) {
+	s, _ := setup(t)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	code, a := get(t, srv.URL+"/users?count=50&seed=7")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 50, len(a))
+
+	_, b := get(t, srv.URL+"/users?count=50&seed=7")
+	assert.Equal(t, a, b)
+
+	age := a[0].(map[string]interface{})["age"].(float64)
+	assert.T(t, age >= 18 && age <= 99)
+
+	code, _ = get(t, srv.URL+"/missing")
+	assert.Equal(t, 404, code)
+
+	code, _ = get(t, srv.URL+"/users?count=-1")
+	assert.Equal(t, 400, code)
+}
+
+func TestStream(t *testing.T) {
+	s, _ := setup(t)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/names?count=3&tick=1ms", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	res, err := http.DefaultClient.Do(req)
+	assert.Equal(t, nil, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	var events []string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+			events = append(events, line)
+		}
+	}
+
+	assert.Equal(t, 3, len(events))
+}
+
+func TestReload(t *testing.T) {
+	s, path := setup(t)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	code, _ := get(t, srv.URL+"/emails")
+	assert.Equal(t, 404, code)
+
+	c := strings.Replace(config, "/names", "/emails", 1)
+	assert.Equal(t, nil, ioutil.WriteFile(path, []byte(c), 0644))
+	later := time.Now().Add(time.Second)
+	assert.Equal(t, nil, os.Chtimes(path, later, later))
+
+	go s.Watch(time.Millisecond, func(error) {})
+
+	for i := 0; i < 100 && code == 404; i++ {
+		time.Sleep(5 * time.Millisecond)
+		code, _ = get(t, srv.URL+"/emails")
+	}
+
+	assert.Equal(t, 200, code)
+}
@@ -0,0 +1,277 @@
+package sink
+
+import "github.com/klauspost/compress/zstd"
+import "compress/gzip"
+import "encoding/json"
+import "path/filepath"
+import "crypto/sha256"
+import "encoding/hex"
+import "io/ioutil"
+import "strings"
+import "bufio"
+import "hash"
+import "fmt"
+import "os"
+import "io"
+
+// Manifest structure.
+type Manifest struct {
+	Files   []*File `json:"files"`
+	Records int     `json:"records"`
+}
+
+// File structure.
+type File struct {
+	Path    string `json:"path"`
+	Records int    `json:"records"`
+	Bytes   int64  `json:"bytes"`
+	SHA256  string `json:"sha256"`
+}
+
+// Files sink, writes records to files named by a pattern such as
+// "out/users-%05d.ndjson", rotating after `opts.FileRecords` records
+// or `opts.FileSize` uncompressed bytes.
+//
+// Files are written to a hidden temporary file and renamed when
+// complete, a manifest listing all files is written on Close next
+// to the files, named `opts.Manifest` or manifest.json.
+//
+// With `opts.Manual` the caller rotates files, see Full, so that
+// encoders can end each file with a complete document.
+type Files struct {
+	pattern  string
+	name     string
+	records  int
+	size     int64
+	compress string
+	manual   bool
+	manifest Manifest
+	current  *File
+	written  int64
+	tmp      string
+	file     *os.File
+	buf      *bufio.Writer
+	zw       io.WriteCloser
+	w        io.Writer
+	hash     hash.Hash
+}
+
+// NewFiles returns a files sink for `pattern`.
+func NewFiles(pattern string, opts Options) (*Files, error) {
+	switch opts.Compress {
+	case "", "none":
+		opts.Compress = ""
+	case "gzip":
+		if !strings.HasSuffix(pattern, ".gz") {
+			pattern += ".gz"
+		}
+	case "zstd":
+		if !strings.HasSuffix(pattern, ".zst") {
+			pattern += ".zst"
+		}
+	default:
+		return nil, fmt.Errorf("sink: unknown compression %q", opts.Compress)
+	}
+
+	rotate := opts.FileRecords > 0 || opts.FileSize > 0
+	if rotate && !strings.Contains(pattern, "%") {
+		return nil, fmt.Errorf("sink: %q must contain a number verb such as %%05d to rotate files", pattern)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pattern), 0755); err != nil {
+		return nil, fmt.Errorf("sink: %s", err)
+	}
+
+	if opts.Manifest == "" {
+		opts.Manifest = "manifest.json"
+	}
+
+	return &Files{
+		pattern:  pattern,
+		name:     opts.Manifest,
+		records:  opts.FileRecords,
+		size:     opts.FileSize,
+		compress: opts.Compress,
+		manual:   opts.Manual,
+	}, nil
+}
+
+// Write `record`, rotating the current file when it is full.
+func (f *Files) Write(record []byte) error {
+	if !f.manual && f.current != nil && f.full(len(record)) {
+		if err := f.close(); err != nil {
+			return err
+		}
+	}
+
+	if err := f.Append(record); err != nil {
+		return err
+	}
+
+	f.current.Records++
+	return nil
+}
+
+// Append `b` to the current file without counting a record,
+// such as a header or the end of a statement.
+func (f *Files) Append(b []byte) error {
+	if f.current == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := f.w.Write(b); err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+
+	f.written += int64(len(b))
+	return nil
+}
+
+// Full reports whether the current file holds enough records or
+// bytes to rotate, files may exceed the size by one record.
+func (f *Files) Full() bool {
+	if f.current == nil {
+		return false
+	}
+	return f.full(0) || f.size > 0 && f.written >= f.size
+}
+
+// Rotate closes the current file, the next write opens a new one.
+func (f *Files) Rotate() error {
+	if f.current == nil {
+		return nil
+	}
+	return f.close()
+}
+
+// Close the current file and write the manifest.
+func (f *Files) Close() error {
+	if f.current != nil {
+		if err := f.close(); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(f.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(filepath.Dir(f.pattern), f.name)
+	tmp := temp(path)
+	if err := ioutil.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+
+	return rename(tmp, path)
+}
+
+// Summary returns the number of files and records written.
+func (f *Files) Summary() string {
+	return fmt.Sprintf("files=%d records=%d", len(f.manifest.Files), f.manifest.Records)
+}
+
+// Errors returns 0, write errors stop the sink.
+func (f *Files) Errors() int {
+	return 0
+}
+
+// Full reports whether the current file is full before writing `n` bytes.
+func (f *Files) full(n int) bool {
+	if f.records > 0 && f.current.Records >= f.records {
+		return true
+	}
+	return f.size > 0 && f.written+int64(n) > f.size
+}
+
+// Open the next file.
+func (f *Files) open() error {
+	path := f.pattern
+	if strings.Contains(path, "%") {
+		path = fmt.Sprintf(f.pattern, len(f.manifest.Files))
+	}
+
+	file, err := os.Create(temp(path))
+	if err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+
+	f.current = &File{Path: path}
+	f.tmp = file.Name()
+	f.file = file
+	f.hash = sha256.New()
+	f.buf = bufio.NewWriter(io.MultiWriter(file, f.hash))
+	f.written = 0
+	f.zw = nil
+	f.w = f.buf
+
+	switch f.compress {
+	case "gzip":
+		f.zw = gzip.NewWriter(f.buf)
+	case "zstd":
+		if f.zw, err = zstd.NewWriter(f.buf); err != nil {
+			file.Close()
+			return fmt.Errorf("sink: %s", err)
+		}
+	}
+
+	if f.zw != nil {
+		f.w = f.zw
+	}
+
+	return nil
+}
+
+// Close the current file, renaming it into place.
+func (f *Files) close() error {
+	if f.zw != nil {
+		if err := f.zw.Close(); err != nil {
+			return fmt.Errorf("sink: %s", err)
+		}
+	}
+
+	if err := f.buf.Flush(); err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+
+	if err := f.file.Sync(); err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+
+	info, err := f.file.Stat()
+	if err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+
+	if err := rename(f.tmp, f.current.Path); err != nil {
+		return err
+	}
+
+	f.current.Bytes = info.Size()
+	f.current.SHA256 = hex.EncodeToString(f.hash.Sum(nil))
+	f.current.Path = filepath.Base(f.current.Path)
+	f.manifest.Files = append(f.manifest.Files, f.current)
+	f.manifest.Records += f.current.Records
+	f.current = nil
+	return nil
+}
+
+// Temporary path of `path`, hidden so that
+// loaders watching the directory skip it.
+func temp(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+}
+
+// Rename `from` to `to`.
+func rename(from, to string) error {
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+	return nil
+}
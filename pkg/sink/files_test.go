@@ -0,0 +1,116 @@
+package sink
+
+import "github.com/klauspost/compress/zstd"
+import "github.com/bmizerany/assert"
+import "path/filepath"
+import "encoding/json"
+import "crypto/sha256"
+import "compress/gzip"
+import "encoding/hex"
+import "io/ioutil"
+import "testing"
+import "bytes"
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewFiles(filepath.Join(dir, "users-%03d.ndjson"), Options{FileRecords: 2, Compress: "gzip"})
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, nil, f.Write([]byte("{}\n")))
+	}
+
+	assert.Equal(t, nil, f.Close())
+	assert.Equal(t, "files=3 records=5", f.Summary())
+
+	names, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Equal(t, 4, len(names))
+
+	var m Manifest
+	b, err := ioutil.ReadFile(filepath.Join(dir, "manifest.json"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, json.Unmarshal(b, &m))
+	assert.Equal(t, 5, m.Records)
+	assert.Equal(t, "users-002.ndjson.gz", m.Files[2].Path)
+	assert.Equal(t, 1, m.Files[2].Records)
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, m.Files[0].Path))
+	assert.Equal(t, nil, err)
+	sum := sha256.Sum256(b)
+	assert.Equal(t, hex.EncodeToString(sum[:]), m.Files[0].SHA256)
+	assert.Equal(t, int64(len(b)), m.Files[0].Bytes)
+
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	assert.Equal(t, nil, err)
+	b, _ = ioutil.ReadAll(r)
+	assert.Equal(t, "{}\n{}\n", string(b))
+}
+
+func TestFilesSize(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewFiles(filepath.Join(dir, "%d.csv"), Options{FileSize: 10, Compress: "zstd"})
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, nil, f.Write([]byte("a,b,c\n")))
+	}
+
+	assert.Equal(t, nil, f.Close())
+	assert.Equal(t, "files=4 records=4", f.Summary())
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "3.csv.zst"))
+	assert.Equal(t, nil, err)
+	r, err := zstd.NewReader(bytes.NewReader(b))
+	assert.Equal(t, nil, err)
+	b, _ = ioutil.ReadAll(r)
+	assert.Equal(t, "a,b,c\n", string(b))
+
+	_, err = NewFiles(filepath.Join(dir, "out.csv"), Options{FileSize: 10})
+	assert.NotEqual(t, nil, err)
+}
+
+func TestFilesManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"a", "b"} {
+		f, err := NewFiles(filepath.Join(dir, name+"-%03d.txt"), Options{FileRecords: 1, Manifest: name + ".manifest.json"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, f.Write([]byte("x\n")))
+		assert.Equal(t, nil, f.Close())
+	}
+
+	for _, name := range []string{"a", "b"} {
+		var m Manifest
+		b, err := ioutil.ReadFile(filepath.Join(dir, name+".manifest.json"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, json.Unmarshal(b, &m))
+		assert.Equal(t, 1, len(m.Files))
+		assert.Equal(t, name+"-000.txt", m.Files[0].Path)
+	}
+}
+
+func TestFilesManual(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewFiles(filepath.Join(dir, "%d.csv"), Options{FileSize: 10, Manual: true})
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 3; i++ {
+		if f.Full() {
+			assert.Equal(t, nil, f.Append([]byte("end\n")))
+			assert.Equal(t, nil, f.Rotate())
+		}
+		assert.Equal(t, nil, f.Write([]byte("a,b,c\n")))
+	}
+
+	assert.Equal(t, nil, f.Append([]byte("end\n")))
+	assert.Equal(t, nil, f.Close())
+	assert.Equal(t, "files=2 records=3", f.Summary())
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "0.csv"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a,b,c\na,b,c\nend\n", string(b))
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "1.csv"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a,b,c\nend\n", string(b))
+}
@@ -0,0 +1,207 @@
+package sink
+
+import "encoding/json"
+import "io/ioutil"
+import "net/http"
+import "strings"
+import "bytes"
+import "sort"
+import "sync"
+import "time"
+import "fmt"
+import "io"
+
+// Maximum delay between retries.
+const maxBackoff = 10 * time.Second
+
+// HTTP sink, sends each record as a request body.
+type HTTP struct {
+	url     string
+	method  string
+	headers []header
+	retries int
+	backoff time.Duration
+	client  *http.Client
+	queue   chan *http.Request
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	status  map[int]int
+	errors  int
+}
+
+// NewHTTP returns an HTTP sink for `url`, requests are sent
+// by `opts.Concurrency` workers over keep-alive connections and
+// retried on errors, 429 and 5xx responses.
+func NewHTTP(url string, opts Options) (*HTTP, error) {
+	hs, err := headers(opts.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Method == "" {
+		opts.Method = "POST"
+	}
+
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	if opts.Backoff <= 0 {
+		opts.Backoff = 100 * time.Millisecond
+	}
+
+	h := &HTTP{
+		url:     url,
+		method:  strings.ToUpper(opts.Method),
+		headers: hs,
+		retries: opts.Retries,
+		backoff: opts.Backoff,
+		queue:   make(chan *http.Request, opts.Concurrency),
+		status:  make(map[int]int),
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConns:        opts.Concurrency,
+				MaxIdleConnsPerHost: opts.Concurrency,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+
+	for i := 0; i < opts.Concurrency; i++ {
+		h.wg.Add(1)
+		go h.work()
+	}
+
+	return h, nil
+}
+
+// Write `record`, blocks while all workers are busy.
+func (h *HTTP) Write(record []byte) error {
+	body := make([]byte, len(record))
+	copy(body, record)
+
+	req, err := http.NewRequest(h.method, h.url, nil)
+	if err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	if json.Valid(body) {
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+
+	for _, hdr := range h.headers {
+		v, err := hdr.value.Execute()
+		if err != nil {
+			return fmt.Errorf("sink: header %s: %s", hdr.name, err)
+		}
+		req.Header.Set(hdr.name, v)
+	}
+
+	h.queue <- req
+	return nil
+}
+
+// Close waits for pending requests.
+func (h *HTTP) Close() error {
+	close(h.queue)
+	h.wg.Wait()
+	h.client.CloseIdleConnections()
+	return nil
+}
+
+// Summary returns the number of responses by status code.
+func (h *HTTP) Summary() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	codes := make([]int, 0, len(h.status))
+	for code := range h.status {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	parts := make([]string, 0, len(codes)+1)
+	for _, code := range codes {
+		parts = append(parts, fmt.Sprintf("%d=%d", code, h.status[code]))
+	}
+
+	if h.errors > 0 {
+		parts = append(parts, fmt.Sprintf("errors=%d", h.errors))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Errors returns the number of failed requests and error responses.
+func (h *HTTP) Errors() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := h.errors
+	for code, count := range h.status {
+		if code >= 400 {
+			n += count
+		}
+	}
+	return n
+}
+
+// Work sends queued requests.
+func (h *HTTP) work() {
+	defer h.wg.Done()
+	for req := range h.queue {
+		code, err := h.send(req)
+		h.mu.Lock()
+		if err != nil {
+			h.errors++
+		} else {
+			h.status[code]++
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Send `req`, retrying with exponential backoff.
+func (h *HTTP) send(req *http.Request) (int, error) {
+	delay := h.backoff
+
+	for attempt := 0; ; attempt++ {
+		body, _ := req.GetBody()
+		req.Body = body
+
+		code, err := h.do(req)
+		if attempt == h.retries || (err == nil && !retry(code)) {
+			return code, err
+		}
+
+		time.Sleep(delay)
+		if delay *= 2; delay > maxBackoff {
+			delay = maxBackoff
+		}
+	}
+}
+
+// Do `req`, draining the body so the connection is reused.
+func (h *HTTP) do(req *http.Request) (int, error) {
+	res, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+	return res.StatusCode, nil
+}
+
+// Retry reports whether a response with `code` should be retried.
+func retry(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
@@ -0,0 +1,70 @@
+package sink
+
+import "github.com/bmizerany/assert"
+import "net/http/httptest"
+import "io/ioutil"
+import "net/http"
+import "testing"
+import "sync"
+import "time"
+
+func TestHTTP(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	var keys = make(map[string]bool)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		keys[r.Header.Get("Idempotency-Key")] = true
+		mu.Unlock()
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	}))
+	defer srv.Close()
+
+	s, err := Open(srv.URL, Options{
+		Method:      "put",
+		Headers:     []string{"Idempotency-Key: {{ uuid }}"},
+		Concurrency: 4,
+	})
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, nil, s.Write([]byte(`{"n":1}`)))
+	}
+
+	assert.Equal(t, nil, s.Close())
+	assert.Equal(t, 20, len(bodies))
+	assert.Equal(t, 20, len(keys))
+	assert.Equal(t, `{"n":1}`, bodies[0])
+	assert.Equal(t, "200=20", s.Summary())
+}
+
+func TestHTTPRetry(t *testing.T) {
+	var mu sync.Mutex
+	var n int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "record", string(b))
+		mu.Lock()
+		defer mu.Unlock()
+		if n++; n%2 == 1 {
+			w.WriteHeader(503)
+		}
+	}))
+	defer srv.Close()
+
+	s, err := NewHTTP(srv.URL, Options{Retries: 1, Backoff: time.Millisecond})
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, nil, s.Write([]byte("record")))
+	}
+
+	assert.Equal(t, nil, s.Close())
+	assert.Equal(t, "200=3", s.Summary())
+	assert.Equal(t, 6, n)
+}
@@ -0,0 +1,208 @@
+package sink
+
+import "github.com/yields/phony/pkg/phony"
+import "github.com/yields/phony/pkg/input"
+import "github.com/segmentio/kafka-go"
+import "encoding/json"
+import "sync/atomic"
+import "net/url"
+import "strconv"
+import "strings"
+import "context"
+import "bytes"
+import "time"
+import "fmt"
+
+// Kafka compression codecs by name.
+var compressions = map[string]kafka.Compression{
+	"none":   0,
+	"gzip":   kafka.Gzip,
+	"snappy": kafka.Snappy,
+	"lz4":    kafka.Lz4,
+	"zstd":   kafka.Zstd,
+}
+
+// Kafka acks by name.
+var acks = map[string]kafka.RequiredAcks{
+	"none": kafka.RequireNone,
+	"0":    kafka.RequireNone,
+	"one":  kafka.RequireOne,
+	"1":    kafka.RequireOne,
+	"all":  kafka.RequireAll,
+	"-1":   kafka.RequireAll,
+}
+
+// Producer interface.
+type producer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Kafka sink, produces batches of records to a topic.
+type Kafka struct {
+	w        producer
+	key      *phony.Template
+	headers  []header
+	batch    []kafka.Message
+	size     int
+	produced int
+	batches  int
+	failures int64
+}
+
+// NewKafka returns a kafka sink for a url such as
+// "kafka://host:9092,host2:9092/topic?acks=all&compression=zstd&batch=100",
+// record keys are rendered from the `opts.Key` template with the
+// record as `.body`, and its fields as `.body.field` for JSON.
+func NewKafka(rawurl string, opts Options) (*Kafka, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("sink: %s", err)
+	}
+
+	topic := strings.Trim(u.Path, "/")
+	if u.Host == "" || topic == "" {
+		return nil, fmt.Errorf("sink: kafka url must be kafka://broker/topic")
+	}
+
+	q := u.Query()
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(strings.Split(u.Host, ",")...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireOne,
+	}
+
+	if opts.Retries > 0 {
+		w.MaxAttempts = opts.Retries + 1
+	}
+
+	if v := q.Get("acks"); v != "" {
+		a, ok := acks[v]
+		if !ok {
+			return nil, fmt.Errorf("sink: unknown kafka acks %q", v)
+		}
+		w.RequiredAcks = a
+	}
+
+	if v := q.Get("compression"); v != "" {
+		c, ok := compressions[v]
+		if !ok {
+			return nil, fmt.Errorf("sink: unknown kafka compression %q", v)
+		}
+		w.Compression = c
+	}
+
+	size := 100
+	if v := q.Get("batch"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size < 1 {
+			return nil, fmt.Errorf("sink: kafka batch must be a positive integer")
+		}
+	}
+	w.BatchSize = size
+
+	return newKafka(w, size, opts)
+}
+
+// Return a kafka sink writing batches of `size` to `w`.
+func newKafka(w producer, size int, opts Options) (*Kafka, error) {
+	hs, err := headers(opts.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Kafka{
+		w:       w,
+		headers: hs,
+		size:    size,
+	}
+
+	if opts.Key != "" {
+		k.key = phony.Compile(opts.Key)
+	}
+
+	return k, nil
+}
+
+// Write `record`, producing a batch when it is full.
+func (k *Kafka) Write(record []byte) error {
+	msg := kafka.Message{Value: make([]byte, len(record))}
+	copy(msg.Value, record)
+
+	vars := body(record)
+
+	if k.key != nil {
+		key, err := k.key.Render(nil, vars)
+		if err != nil {
+			return fmt.Errorf("sink: key: %s", err)
+		}
+		msg.Key = []byte(key)
+	}
+
+	for _, h := range k.headers {
+		v, err := h.value.Render(nil, vars)
+		if err != nil {
+			return fmt.Errorf("sink: header %s: %s", h.name, err)
+		}
+		msg.Headers = append(msg.Headers, kafka.Header{Key: h.name, Value: []byte(v)})
+	}
+
+	if k.batch = append(k.batch, msg); len(k.batch) < k.size {
+		return nil
+	}
+
+	return k.flush()
+}
+
+// Close produces the last batch and closes the producer.
+func (k *Kafka) Close() error {
+	if err := k.flush(); err != nil {
+		k.w.Close()
+		return err
+	}
+	return k.w.Close()
+}
+
+// Summary returns the number of records and batches produced.
+func (k *Kafka) Summary() string {
+	return fmt.Sprintf("produced=%d batches=%d", k.produced, k.batches)
+}
+
+// Errors returns the number of records that could not be produced.
+func (k *Kafka) Errors() int {
+	return int(atomic.LoadInt64(&k.failures))
+}
+
+// Produce the pending batch.
+func (k *Kafka) flush() error {
+	if len(k.batch) == 0 {
+		return nil
+	}
+
+	if err := k.w.WriteMessages(context.Background(), k.batch...); err != nil {
+		atomic.AddInt64(&k.failures, int64(len(k.batch)))
+		return fmt.Errorf("sink: kafka: %s", err)
+	}
+
+	k.produced += len(k.batch)
+	k.batches++
+	k.batch = nil
+	return nil
+}
+
+// Variables of `record`, the record is `.body` and
+// the fields of a JSON object are `.body.field`.
+func body(record []byte) phony.Vars {
+	vars := make(phony.Vars)
+
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(record))
+	d.UseNumber()
+	if d.Decode(&v) == nil {
+		input.Flatten(vars, "body", v)
+	}
+
+	vars["body"] = strings.TrimRight(string(record), "\r\n")
+	return vars
+}
@@ -0,0 +1,96 @@
+package sink
+
+import "github.com/segmentio/kafka-go"
+import "github.com/bmizerany/assert"
+import "context"
+import "testing"
+import "time"
+import "os"
+
+type fakeProducer struct {
+	batches [][]kafka.Message
+	closed  bool
+}
+
+func (p *fakeProducer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	p.batches = append(p.batches, msgs)
+	return nil
+}
+
+func (p *fakeProducer) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestKafka(t *testing.T) {
+	p := &fakeProducer{}
+	k, err := newKafka(p, 2, Options{
+		Key:     "{{ .body.user }}",
+		Headers: []string{"source: phony"},
+	})
+	assert.Equal(t, nil, err)
+
+	for _, user := range []string{"alice", "bob", "carol"} {
+		assert.Equal(t, nil, k.Write([]byte(`{"user":"`+user+`"}`)))
+	}
+
+	assert.Equal(t, 1, len(p.batches))
+	assert.Equal(t, nil, k.Close())
+	assert.Equal(t, 2, len(p.batches))
+	assert.Equal(t, 1, len(p.batches[1]))
+	assert.T(t, p.closed)
+
+	msg := p.batches[0][0]
+	assert.Equal(t, `{"user":"alice"}`, string(msg.Value))
+	assert.Equal(t, "alice", string(msg.Key))
+	assert.Equal(t, "bob", string(p.batches[0][1].Key))
+	assert.Equal(t, "source", msg.Headers[0].Key)
+	assert.Equal(t, "phony", string(msg.Headers[0].Value))
+	assert.Equal(t, "produced=3 batches=2", k.Summary())
+}
+
+func TestKafkaURL(t *testing.T) {
+	k, err := NewKafka("kafka://a:9092,b:9092/events?acks=all&compression=zstd&batch=50", Options{})
+	assert.Equal(t, nil, err)
+
+	w := k.w.(*kafka.Writer)
+	assert.Equal(t, "events", w.Topic)
+	assert.Equal(t, kafka.RequireAll, w.RequiredAcks)
+	assert.Equal(t, kafka.Zstd, w.Compression)
+	assert.Equal(t, 50, k.size)
+	assert.Equal(t, "a:9092,b:9092", w.Addr.String())
+
+	_, err = NewKafka("kafka://a:9092", Options{})
+	assert.NotEqual(t, nil, err)
+}
+
+// Produces to a local broker when $PHONY_KAFKA is set,
+// e.g PHONY_KAFKA=localhost:9092.
+func TestKafkaBroker(t *testing.T) {
+	addr := os.Getenv("PHONY_KAFKA")
+	if addr == "" {
+		t.Skip("PHONY_KAFKA is not set")
+	}
+
+	topic := "phony-" + time.Now().Format("20060102150405")
+	c, err := kafka.Dial("tcp", addr)
+	assert.Equal(t, nil, err)
+	defer c.Close()
+	assert.Equal(t, nil, c.CreateTopics(kafka.TopicConfig{Topic: topic, NumPartitions: 1, ReplicationFactor: 1}))
+
+	k, err := NewKafka("kafka://"+addr+"/"+topic, Options{Key: "{{ .body.user }}"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, k.Write([]byte(`{"user":"alice"}`)))
+	assert.Equal(t, nil, k.Close())
+
+	r := kafka.NewReader(kafka.ReaderConfig{Brokers: []string{addr}, Topic: topic})
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	msg, err := r.ReadMessage(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "alice", string(msg.Key))
+	assert.Equal(t, `{"user":"alice"}`, string(msg.Value))
+}
@@ -0,0 +1,82 @@
+package sink
+
+import "github.com/yields/phony/pkg/phony"
+import "net/url"
+import "strings"
+import "time"
+import "fmt"
+
+// Sink interface.
+//
+// Write sends a single record, Close waits for pending
+// records and Summary describes what was sent. Errors returns
+// the number of records that could not be delivered and is
+// safe to call concurrently.
+type Sink interface {
+	Write(record []byte) error
+	Close() error
+	Summary() string
+	Errors() int
+}
+
+// Options structure.
+type Options struct {
+	Method      string
+	Headers     []string
+	Concurrency int
+	Retries     int
+	Backoff     time.Duration
+	Framing     string
+	Key         string
+	FileRecords int
+	FileSize    int64
+	Compress    string
+	Manifest    string
+	Manual      bool
+}
+
+// Header structure.
+type header struct {
+	name  string
+	value *phony.Template
+}
+
+// Open a sink for `rawurl`.
+func Open(rawurl string, opts Options) (Sink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("sink: %s", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return NewHTTP(rawurl, opts)
+	case "tcp", "udp":
+		return NewSocket(u.Scheme, u.Host, opts)
+	case "unix":
+		return NewSocket(u.Scheme, u.Path, opts)
+	case "kafka":
+		return NewKafka(rawurl, opts)
+	default:
+		return nil, fmt.Errorf("sink: unsupported scheme %q", u.Scheme)
+	}
+}
+
+// Parse "Name: value" headers, values are templates.
+func headers(list []string) ([]header, error) {
+	ret := make([]header, len(list))
+
+	for i, h := range list {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("sink: invalid header %q", h)
+		}
+
+		ret[i] = header{
+			name:  strings.TrimSpace(parts[0]),
+			value: phony.Compile(strings.TrimSpace(parts[1])),
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,143 @@
+package sink
+
+import "sync/atomic"
+import "strconv"
+import "bytes"
+import "time"
+import "net"
+import "fmt"
+
+// Socket write timeout.
+const writeTimeout = 30 * time.Second
+
+// Socket sink, writes framed records to a tcp,
+// udp or unix socket, reconnecting on failure.
+type Socket struct {
+	network    string
+	addr       string
+	octets     bool
+	retries    int
+	backoff    time.Duration
+	conn       net.Conn
+	sent       int
+	reconnects int
+	failures   int64
+}
+
+// NewSocket returns a socket sink for `addr`, records are
+// newline terminated unless `opts.Framing` is "octet" which
+// prefixes each record with its length as in RFC 6587.
+func NewSocket(network, addr string, opts Options) (*Socket, error) {
+	s := &Socket{
+		network: network,
+		addr:    addr,
+		retries: opts.Retries,
+		backoff: opts.Backoff,
+	}
+
+	switch opts.Framing {
+	case "", "newline":
+	case "octet":
+		s.octets = true
+	default:
+		return nil, fmt.Errorf("sink: unknown framing %q", opts.Framing)
+	}
+
+	if s.backoff <= 0 {
+		s.backoff = 100 * time.Millisecond
+	}
+
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Write `record`, reconnecting up to `retries` times.
+func (s *Socket) Write(record []byte) error {
+	b := s.frame(record)
+	delay := s.backoff
+
+	for attempt := 0; ; attempt++ {
+		err := s.write(b)
+		if err == nil {
+			s.sent++
+			return nil
+		}
+
+		if attempt == s.retries {
+			atomic.AddInt64(&s.failures, 1)
+			return fmt.Errorf("sink: %s", err)
+		}
+
+		time.Sleep(delay)
+		if delay *= 2; delay > maxBackoff {
+			delay = maxBackoff
+		}
+	}
+}
+
+// Close the connection.
+func (s *Socket) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// Summary returns the number of records sent and reconnects.
+func (s *Socket) Summary() string {
+	return fmt.Sprintf("sent=%d reconnects=%d", s.sent, s.reconnects)
+}
+
+// Errors returns the number of records that could not be written.
+func (s *Socket) Errors() int {
+	return int(atomic.LoadInt64(&s.failures))
+}
+
+// Write `b`, reconnecting first if the last write failed.
+func (s *Socket) write(b []byte) error {
+	if s.conn == nil {
+		if err := s.dial(); err != nil {
+			return err
+		}
+		s.reconnects++
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := s.conn.Write(b); err != nil {
+		s.Close()
+		return err
+	}
+
+	return nil
+}
+
+// Dial the socket.
+func (s *Socket) dial() error {
+	conn, err := net.DialTimeout(s.network, s.addr, writeTimeout)
+	if err != nil {
+		return fmt.Errorf("sink: %s", err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// Frame `record`.
+func (s *Socket) frame(record []byte) []byte {
+	if s.octets {
+		record = bytes.TrimSuffix(record, []byte("\n"))
+		b := strconv.AppendInt(nil, int64(len(record)), 10)
+		b = append(b, ' ')
+		return append(b, record...)
+	}
+
+	if bytes.HasSuffix(record, []byte("\n")) {
+		return record
+	}
+
+	return append(record[:len(record):len(record)], '\n')
+}
@@ -0,0 +1,86 @@
+package sink
+
+import "github.com/bmizerany/assert"
+import "path/filepath"
+import "io/ioutil"
+import "testing"
+import "bufio"
+import "time"
+import "net"
+
+func TestTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	defer ln.Close()
+
+	s, err := Open("tcp://"+ln.Addr().String(), Options{})
+	assert.Equal(t, nil, err)
+
+	conn, err := ln.Accept()
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, nil, s.Write([]byte("a.b.c 1 1700000000")))
+	assert.Equal(t, nil, s.Write([]byte("a.b.c 2 1700000001\n")))
+	assert.Equal(t, nil, s.Close())
+
+	b, err := ioutil.ReadAll(conn)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a.b.c 1 1700000000\na.b.c 2 1700000001\n", string(b))
+	assert.Equal(t, "sent=2 reconnects=0", s.Summary())
+}
+
+func TestUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	defer conn.Close()
+
+	s, err := Open("udp://"+conn.LocalAddr().String(), Options{Framing: "octet"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, s.Write([]byte("<34>1 - host app - - - hello\n")))
+	assert.Equal(t, nil, s.Close())
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "28 <34>1 - host app - - - hello", string(buf[:n]))
+}
+
+func TestUnixReconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "phony.sock")
+	ln, err := net.Listen("unix", path)
+	assert.Equal(t, nil, err)
+	defer ln.Close()
+
+	s, err := Open("unix://"+path, Options{Retries: 3, Backoff: time.Millisecond})
+	assert.Equal(t, nil, err)
+
+	conn, err := ln.Accept()
+	assert.Equal(t, nil, err)
+	conn.Close()
+
+	lines := make(chan string, 100)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, nil, s.Write([]byte("record")))
+		select {
+		case line := <-lines:
+			assert.Equal(t, "record", line)
+			assert.Equal(t, nil, s.Close())
+			return
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	t.Fatal("sink did not reconnect")
+}
@@ -0,0 +1,111 @@
+package stats
+
+import "sync/atomic"
+import "net/http"
+import "strings"
+import "time"
+import "fmt"
+import "io"
+
+// Stats structure, safe for concurrent use.
+type Stats struct {
+	start   time.Time
+	records int64
+	bytes   int64
+	errors  func() int
+	lag     int64
+}
+
+// New returns stats started now, `errors` returns
+// the number of sink errors and may be nil.
+func New(errors func() int) *Stats {
+	if errors == nil {
+		errors = func() int { return 0 }
+	}
+	return &Stats{start: time.Now(), errors: errors}
+}
+
+// Record counts a record.
+func (s *Stats) Record() {
+	atomic.AddInt64(&s.records, 1)
+}
+
+// Lag sets how far generation is behind schedule.
+func (s *Stats) Lag(d time.Duration) {
+	atomic.StoreInt64(&s.lag, int64(d))
+}
+
+// Writer returns a writer counting bytes written to `w`.
+func (s *Stats) Writer(w io.Writer) io.Writer {
+	return &writer{w: w, s: s}
+}
+
+// Report writes stats to `w` every `interval` until `done` is closed.
+func (s *Stats) Report(w io.Writer, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := int64(0)
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		records := atomic.LoadInt64(&s.records)
+		rate := float64(records-last) / interval.Seconds()
+		last = records
+
+		fmt.Fprintf(w, "phony: records=%d bytes=%s rate=%.0f/s lag=%s errors=%d\n",
+			records,
+			size(atomic.LoadInt64(&s.bytes)),
+			rate,
+			time.Duration(atomic.LoadInt64(&s.lag)).Round(time.Millisecond),
+			s.errors())
+	}
+}
+
+// ServeHTTP responds with the stats in the prometheus text format.
+func (s *Stats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+
+	metric := func(name, typ, help string, v float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, typ, name, v)
+	}
+
+	metric("phony_records_total", "counter", "Records generated.", float64(atomic.LoadInt64(&s.records)))
+	metric("phony_bytes_total", "counter", "Bytes written.", float64(atomic.LoadInt64(&s.bytes)))
+	metric("phony_sink_errors_total", "counter", "Records the sink failed to deliver.", float64(s.errors()))
+	metric("phony_lag_seconds", "gauge", "How far generation is behind schedule.", time.Duration(atomic.LoadInt64(&s.lag)).Seconds())
+	metric("phony_start_time_seconds", "gauge", "Start time since the unix epoch.", float64(s.start.UnixNano())/1e9)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	io.WriteString(w, b.String())
+}
+
+// Writer structure.
+type writer struct {
+	w io.Writer
+	s *Stats
+}
+
+// Write implementation.
+func (w *writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	atomic.AddInt64(&w.s.bytes, int64(n))
+	return n, err
+}
+
+// Format `n` bytes.
+func size(n int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	f, i := float64(n), 0
+	for f >= 1024 && i < len(units)-1 {
+		f, i = f/1024, i+1
+	}
+	if i == 0 {
+		return fmt.Sprintf("%dB", n)
+	}
+	return fmt.Sprintf("%.1f%s", f, units[i])
+}
@@ -0,0 +1,58 @@
+package stats
+
+import "github.com/bmizerany/assert"
+import "net/http/httptest"
+import "io/ioutil"
+import "strings"
+import "testing"
+import "bytes"
+import "time"
+
+func TestStats(t *testing.T) {
+	s := New(func() int { return 2 })
+
+	var buf bytes.Buffer
+	w := s.Writer(&buf)
+	for i := 0; i < 3; i++ {
+		w.Write([]byte("record\n"))
+		s.Record()
+	}
+	s.Lag(1500 * time.Millisecond)
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	res, err := srv.Client().Get(srv.URL + "/metrics")
+	assert.Equal(t, nil, err)
+	b, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	body := string(b)
+	assert.T(t, strings.Contains(body, "# TYPE phony_records_total counter\nphony_records_total 3\n"), body)
+	assert.T(t, strings.Contains(body, "\nphony_bytes_total 21\n"), body)
+	assert.T(t, strings.Contains(body, "\nphony_sink_errors_total 2\n"), body)
+	assert.T(t, strings.Contains(body, "\nphony_lag_seconds 1.5\n"), body)
+}
+
+func TestReport(t *testing.T) {
+	s := New(nil)
+	s.Record()
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(25 * time.Millisecond)
+		close(done)
+	}()
+
+	s.Report(&buf, 10*time.Millisecond, done)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.T(t, len(lines) >= 1, buf.String())
+	assert.T(t, strings.HasPrefix(lines[0], "phony: records=1 bytes=0B rate=100/s lag=0s errors=0"), lines[0])
+}
+
+func TestSize(t *testing.T) {
+	assert.Equal(t, "512B", size(512))
+	assert.Equal(t, "1.5KB", size(1536))
+	assert.Equal(t, "2.0GB", size(2<<30))
+}